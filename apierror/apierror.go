@@ -0,0 +1,194 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// Kind is the machine readable category of an error, it decides the HTTP status and is returned as the problem code
+type Kind string
+
+const (
+	// KindBadRequest is for requests that can't be read at all, such as malformed JSON or a bad URL
+	KindBadRequest Kind = "bad_request"
+	// KindValidation is for requests that could be read but contain invalid values
+	KindValidation Kind = "validation"
+	// KindUnauthorized is for requests without valid credentials
+	KindUnauthorized Kind = "unauthorized"
+	// KindForbidden is for requests whose credentials don't allow what they are trying to do
+	KindForbidden Kind = "forbidden"
+	// KindNotFound is for anything that refers to a table, reservation or other resource that doesn't exist
+	KindNotFound Kind = "not_found"
+	// KindConflict is for requests that clash with the current state, such as a duplicate table or guest
+	KindConflict Kind = "conflict"
+	// KindCapacityExceeded is for reservations and arrivals that don't fit on their table
+	KindCapacityExceeded Kind = "capacity_exceeded"
+	// KindPreconditionFailed is for writes with an If-Match that no longer matches, someone else changed it first
+	KindPreconditionFailed Kind = "precondition_failed"
+	// KindUnavailable is for requests that arrive before the server can handle them, such as while it connects to the
+	// database
+	KindUnavailable Kind = "unavailable"
+	// KindInternal is for everything that went wrong on our side
+	KindInternal Kind = "internal"
+)
+
+var statuses = map[Kind]int{
+	KindBadRequest:         http.StatusBadRequest,
+	KindValidation:         http.StatusUnprocessableEntity,
+	KindUnauthorized:       http.StatusUnauthorized,
+	KindForbidden:          http.StatusForbidden,
+	KindNotFound:           http.StatusNotFound,
+	KindConflict:           http.StatusConflict,
+	KindCapacityExceeded:   http.StatusConflict,
+	KindPreconditionFailed: http.StatusPreconditionFailed,
+	KindUnavailable:        http.StatusServiceUnavailable,
+	KindInternal:           http.StatusInternalServerError,
+}
+
+// Error is an error with a Kind, the detail is safe to show to whoever made the request
+type Error struct {
+	Kind   Kind
+	Detail string
+	// Extra is added to the problem body under "errors", for example the rows of an import that failed validation
+	Extra interface{}
+	Err   error
+}
+
+// Error is the detail followed by the wrapped error, if there is one, so it should only be logged
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Detail, e.Err)
+	}
+	return e.Detail
+}
+
+// Unwrap returns the wrapped error, so errors.Is and errors.As can see through an *Error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New creates an error of the given kind
+func New(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Detail: fmt.Sprintf(format, args...)}
+}
+
+// BadRequest creates a KindBadRequest error, a 400 with the urn:guest-list:problem:bad_request type
+func BadRequest(format string, args ...interface{}) *Error {
+	return New(KindBadRequest, format, args...)
+}
+
+// Validation creates a KindValidation error, a 422 with the urn:guest-list:problem:validation type
+func Validation(format string, args ...interface{}) *Error {
+	return New(KindValidation, format, args...)
+}
+
+// Unauthorized creates a KindUnauthorized error, a 401 with the urn:guest-list:problem:unauthorized type
+func Unauthorized(format string, args ...interface{}) *Error {
+	return New(KindUnauthorized, format, args...)
+}
+
+// Forbidden creates a KindForbidden error, a 403 with the urn:guest-list:problem:forbidden type
+func Forbidden(format string, args ...interface{}) *Error {
+	return New(KindForbidden, format, args...)
+}
+
+// NotFound creates a KindNotFound error, a 404 with the urn:guest-list:problem:not_found type
+func NotFound(format string, args ...interface{}) *Error {
+	return New(KindNotFound, format, args...)
+}
+
+// Conflict creates a KindConflict error, a 409 with the urn:guest-list:problem:conflict type
+func Conflict(format string, args ...interface{}) *Error {
+	return New(KindConflict, format, args...)
+}
+
+// CapacityExceeded creates a KindCapacityExceeded error, a 409 with the urn:guest-list:problem:capacity_exceeded type
+func CapacityExceeded(format string, args ...interface{}) *Error {
+	return New(KindCapacityExceeded, format, args...)
+}
+
+// PreconditionFailed creates a KindPreconditionFailed error, a 412 with the
+// urn:guest-list:problem:precondition_failed type
+func PreconditionFailed(format string, args ...interface{}) *Error {
+	return New(KindPreconditionFailed, format, args...)
+}
+
+// Unavailable creates a KindUnavailable error, a 503 with the urn:guest-list:problem:unavailable type
+func Unavailable(format string, args ...interface{}) *Error {
+	return New(KindUnavailable, format, args...)
+}
+
+// Internal wraps an unexpected error as a KindInternal error, a 500 with the urn:guest-list:problem:internal type. The
+// wrapped error is kept for logging but never sent to whoever made the request
+func Internal(err error, format string, args ...interface{}) *Error {
+	e := New(KindInternal, format, args...)
+	e.Err = err
+	return e
+}
+
+// KindOf finds the kind of any error, gorm's record not found is treated as not found and anything untyped is internal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return KindNotFound
+	}
+	return KindInternal
+}
+
+// Message is the part of an error that is safe to send to whoever made the request, the detail of an *Error without
+// the error it wraps. Untyped internal errors, which could say anything about the database, get a generic message
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Detail
+	}
+	if KindOf(err) == KindInternal {
+		return "something went wrong handling the request"
+	}
+	return err.Error()
+}
+
+// Status returns the HTTP status code for a kind
+func Status(kind Kind) int {
+	if status, ok := statuses[kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Problem is an RFC 7807 problem details body with the kind added as code
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     Kind        `json:"code"`
+	Errors   interface{} `json:"errors,omitempty"`
+}
+
+// ToProblem converts any error into a problem, instance should be the path of the request that failed. Only the
+// error's Message is included, the caller should log the error itself
+func ToProblem(err error, instance string) Problem {
+	kind := KindOf(err)
+	status := Status(kind)
+
+	p := Problem{
+		Type:     "urn:guest-list:problem:" + string(kind),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   Message(err),
+		Instance: instance,
+		Code:     kind,
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		p.Errors = e.Extra
+	}
+	return p
+}
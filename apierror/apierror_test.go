@@ -0,0 +1,70 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"testing"
+)
+
+func TestToProblem(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected Problem
+	}{
+		{
+			"notFound",
+			NotFound("table 1 does not exist"),
+			Problem{"urn:guest-list:problem:not_found", "Not Found", http.StatusNotFound, "table 1 does not exist", "/table/1", KindNotFound, nil},
+		},
+		{
+			"gormNotFound",
+			fmt.Errorf("failed to find table: %w", gorm.ErrRecordNotFound),
+			Problem{"urn:guest-list:problem:not_found", "Not Found", http.StatusNotFound, "failed to find table: record not found", "/table/1", KindNotFound, nil},
+		},
+		{
+			"conflict",
+			Conflict("a table exists with that number already"),
+			Problem{"urn:guest-list:problem:conflict", "Conflict", http.StatusConflict, "a table exists with that number already", "/table/1", KindConflict, nil},
+		},
+		{
+			"capacity",
+			CapacityExceeded("not enough seats available on selected table"),
+			Problem{"urn:guest-list:problem:capacity_exceeded", "Conflict", http.StatusConflict, "not enough seats available on selected table", "/table/1", KindCapacityExceeded, nil},
+		},
+		{
+			"precondition",
+			PreconditionFailed("table 1 has been changed"),
+			Problem{"urn:guest-list:problem:precondition_failed", "Precondition Failed", http.StatusPreconditionFailed, "table 1 has been changed", "/table/1", KindPreconditionFailed, nil},
+		},
+		{
+			"unavailable",
+			Unavailable("the server is starting"),
+			Problem{"urn:guest-list:problem:unavailable", "Service Unavailable", http.StatusServiceUnavailable, "the server is starting", "/table/1", KindUnavailable, nil},
+		},
+		{
+			"validation",
+			&Error{Kind: KindValidation, Detail: "invalid rows", Extra: []string{"row 1"}},
+			Problem{"urn:guest-list:problem:validation", "Unprocessable Entity", http.StatusUnprocessableEntity, "invalid rows", "/table/1", KindValidation, []string{"row 1"}},
+		},
+		{
+			"internal",
+			Internal(errors.New("connection refused"), "failed to load table"),
+			Problem{"urn:guest-list:problem:internal", "Internal Server Error", http.StatusInternalServerError, "failed to load table", "/table/1", KindInternal, nil},
+		},
+		{
+			"untyped",
+			errors.New("Error 1045: Access denied for user 'root'@'10.0.0.2'"),
+			Problem{"urn:guest-list:problem:internal", "Internal Server Error", http.StatusInternalServerError, "something went wrong handling the request", "/table/1", KindInternal, nil},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, ToProblem(c.err, "/table/1"))
+		})
+	}
+}
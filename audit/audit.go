@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/requestid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// The actions recorded against tables and reservations
+const (
+	ActionCreate      = "create"
+	ActionUpdate      = "update"
+	ActionDelete      = "delete"
+	ActionMove        = "move"
+	ActionCheckIn     = "check_in"
+	ActionUndoCheckIn = "undo_check_in"
+	ActionUndelete    = "undelete"
+	ActionPurge       = "purge"
+	ActionRestore     = "restore"
+	ActionFixture     = "fixture"
+)
+
+const (
+	ResourceTable       = "table"
+	ResourceReservation = "reservation"
+)
+
+// Anonymous is the actor recorded for changes made without credentials, which only happens when authentication is
+// disabled or outside of a request
+const Anonymous = "anonymous"
+
+// TableState is what a table looked like at the time of a change
+type TableState struct {
+	Number  int  `json:"number"`
+	Seats   int  `json:"seats"`
+	Version uint `json:"version"`
+}
+
+// ReservationState is what a reservation looked like at the time of a change
+type ReservationState struct {
+	Guest              string     `json:"name"`
+	Table              int        `json:"table"`
+	AccompanyingGuests int        `json:"accompanying_guests"`
+	ArrivalTime        *time.Time `json:"arrival_time"`
+	Version            uint       `json:"version"`
+}
+
+// Filter narrows down a search of the audit log, empty fields match everything
+type Filter struct {
+	Guest       string
+	TableNumber *int
+	Actor       string
+	Since       *time.Time
+	Until       *time.Time
+	// BeforeID only returns entries older than the one with this ID, for paging
+	BeforeID uint
+	Limit    int
+}
+
+// Table records a change to a table, before is nil for a new table and after is nil for a deleted one
+func Table(db *gorm.DB, action string, before, after *model.Table) error {
+	entry := model.AuditEntry{Action: action, Resource: ResourceTable}
+	for _, t := range []*model.Table{before, after} {
+		if t == nil {
+			continue
+		}
+		number := t.Number
+		entry.TableNumber = &number
+	}
+	return record(db, entry, tableState(before), tableState(after))
+}
+
+// Reservation records a change to a reservation, before is nil for a new reservation and after is nil for a deleted
+// one. The reservations must have their table loaded
+func Reservation(db *gorm.DB, action string, before, after *model.Reservation) error {
+	entry := model.AuditEntry{Action: action, Resource: ResourceReservation}
+	for _, r := range []*model.Reservation{before, after} {
+		if r == nil {
+			continue
+		}
+		number := r.Table.Number
+		entry.Guest = r.Guest
+		entry.TableNumber = &number
+	}
+	return record(db, entry, reservationState(before), reservationState(after))
+}
+
+// Search finds audit entries matching the filter, newest first
+func Search(db *gorm.DB, f Filter) ([]model.AuditEntry, error) {
+	query := db.Model(&model.AuditEntry{})
+	if f.Guest != "" {
+		query = query.Where("guest = ?", f.Guest)
+	}
+	if f.TableNumber != nil {
+		query = query.Where("table_number = ?", *f.TableNumber)
+	}
+	if f.Actor != "" {
+		query = query.Where("actor = ?", f.Actor)
+	}
+	if f.Since != nil {
+		query = query.Where("created_at >= ?", *f.Since)
+	}
+	if f.Until != nil {
+		query = query.Where("created_at < ?", *f.Until)
+	}
+	if f.BeforeID != 0 {
+		query = query.Where("id < ?", f.BeforeID)
+	}
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+
+	entries := []model.AuditEntry{}
+	err := query.Order("id DESC").Find(&entries).Error
+	return entries, err
+}
+
+// record fills in who made the change from the request the database handle belongs to and saves the entry. It uses
+// the same handle as the change so the entry is only kept if the change is
+func record(db *gorm.DB, entry model.AuditEntry, before, after interface{}) error {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	entry.Actor = Anonymous
+	if principal, ok := auth.FromContext(ctx); ok {
+		entry.Actor = principal.Name
+		entry.AuthMethod = principal.Method
+	}
+	entry.RequestID = requestid.FromContext(ctx)
+
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = snapshot(after); err != nil {
+		return err
+	}
+	return db.Create(&entry).Error
+}
+
+func snapshot(state interface{}) (string, error) {
+	if state == nil {
+		return "", nil
+	}
+	out, err := json.Marshal(state)
+	return string(out), err
+}
+
+// tableState and reservationState return an untyped nil for missing rows so snapshot can tell there is nothing to save
+func tableState(t *model.Table) interface{} {
+	if t == nil {
+		return nil
+	}
+	return TableState{Number: t.Number, Seats: t.Seats, Version: t.Version}
+}
+
+func reservationState(r *model.Reservation) interface{} {
+	if r == nil {
+		return nil
+	}
+	return ReservationState{
+		Guest:              r.Guest,
+		Table:              r.Table.Number,
+		AccompanyingGuests: r.AccompanyingGuests,
+		ArrivalTime:        r.ArrivalTime,
+		Version:            r.Version,
+	}
+}
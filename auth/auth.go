@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/ctompkinson/guest-list/model"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// Role is what a caller is allowed to do, every API key and token maps to exactly one
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RolePlanner   Role = "planner"
+	RoleDoorStaff Role = "door_staff"
+	RoleReadOnly  Role = "read_only"
+)
+
+// Permission is what a route needs, routes are given one in server.NewRouter
+type Permission string
+
+const (
+	// Read is for looking at tables, reservations, arrivals and seats
+	Read Permission = "read"
+	// CheckIn is for checking guests in and out
+	CheckIn Permission = "check_in"
+	// Plan is for changing tables and reservations
+	Plan Permission = "plan"
+	// Admin is for webhooks, backups, restores and API keys
+	Admin Permission = "admin"
+)
+
+// KeyPrefix starts every API key so they are easy to tell apart from other tokens
+const KeyPrefix = "gl_"
+
+var (
+	// Disabled lets every request through as an admin, only for local development
+	Disabled = false
+
+	// Roles lists every role in order of how much they can do
+	Roles = []Role{RoleAdmin, RolePlanner, RoleDoorStaff, RoleReadOnly}
+
+	permissions = map[Role][]Permission{
+		RoleAdmin:     {Read, CheckIn, Plan, Admin},
+		RolePlanner:   {Read, CheckIn, Plan},
+		RoleDoorStaff: {Read, CheckIn},
+		RoleReadOnly:  {Read},
+	}
+
+	// ErrInvalidKey is returned for API keys that don't exist or have been revoked
+	ErrInvalidKey = errors.New("invalid API key")
+)
+
+// Principal is whoever made a request
+type Principal struct {
+	// Name identifies the caller, the API key's name or the token's subject
+	Name string
+	Role Role
+	// Method is how they authenticated, api_key or jwt, or cli and system for changes made outside of a request
+	Method string
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores who made a request in its context
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext gets who made a request, ok is false for anonymous requests
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// IsRole checks if the given string is a known role
+func IsRole(role string) bool {
+	_, ok := permissions[Role(role)]
+	return ok
+}
+
+// Allows checks if a role has a permission
+func (r Role) Allows(permission Permission) bool {
+	for _, p := range permissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// NewKey generates a new random API key
+func NewKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return KeyPrefix + hex.EncodeToString(b), nil
+}
+
+// HashKey hashes an API key for storage. Keys are long and random so a fast hash is enough to make a stolen database
+// useless without slowing down every request
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey stores a new API key, only its hash and the first few characters are kept
+func CreateKey(db *gorm.DB, name string, role Role, key string) (model.APIKey, error) {
+	apiKey := model.APIKey{
+		Name:   name,
+		Role:   string(role),
+		Prefix: displayPrefix(key),
+		Hash:   HashKey(key),
+	}
+	err := db.Create(&apiKey).Error
+	return apiKey, err
+}
+
+// EnsureKey stores an API key if it isn't already, used to give a fresh install its first admin key
+func EnsureKey(db *gorm.DB, name string, role Role, key string) error {
+	var count int64
+	if err := db.Model(&model.APIKey{}).Where("hash = ?", HashKey(key)).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := CreateKey(db, name, role, key)
+	return err
+}
+
+// Authenticate finds the API key a request was made with and records when it was last used
+func Authenticate(db *gorm.DB, key string) (Principal, error) {
+	if !strings.HasPrefix(key, KeyPrefix) {
+		return Principal{}, ErrInvalidKey
+	}
+
+	var apiKey model.APIKey
+	if err := db.Where("hash = ?", HashKey(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Principal{}, ErrInvalidKey
+		}
+		return Principal{}, err
+	}
+
+	// Only used to show which keys are still in use, so failing to save it isn't worth failing the request
+	db.Model(&apiKey).UpdateColumn("last_used_at", time.Now())
+
+	return Principal{Name: apiKey.Name, Role: Role(apiKey.Role), Method: "api_key"}, nil
+}
+
+// displayPrefix is enough of a key to recognise it in a list without being able to use it
+func displayPrefix(key string) string {
+	if len(key) > len(KeyPrefix)+8 {
+		return key[:len(KeyPrefix)+8]
+	}
+	return key
+}
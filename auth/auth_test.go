@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRoleAllows(t *testing.T) {
+	cases := []struct {
+		role    Role
+		allowed []Permission
+		denied  []Permission
+	}{
+		{RoleAdmin, []Permission{Read, CheckIn, Plan, Admin}, nil},
+		{RolePlanner, []Permission{Read, CheckIn, Plan}, []Permission{Admin}},
+		{RoleDoorStaff, []Permission{Read, CheckIn}, []Permission{Plan, Admin}},
+		{RoleReadOnly, []Permission{Read}, []Permission{CheckIn, Plan, Admin}},
+		{Role("guest"), nil, []Permission{Read, CheckIn, Plan, Admin}},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.role), func(t *testing.T) {
+			for _, p := range c.allowed {
+				assert.True(t, c.role.Allows(p), "%s should have %s", c.role, p)
+			}
+			for _, p := range c.denied {
+				assert.False(t, c.role.Allows(p), "%s shouldn't have %s", c.role, p)
+			}
+		})
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	key, err := NewKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, KeyPrefix))
+
+	other, err := NewKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+	assert.NotEqual(t, HashKey(key), HashKey(other))
+	assert.Len(t, HashKey(key), 64)
+	assert.Equal(t, key[:11], displayPrefix(key))
+}
+
+func TestPrincipalContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithPrincipal(context.Background(), Principal{Name: "door", Role: RoleDoorStaff, Method: "api_key"})
+	p, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, RoleDoorStaff, p.Role)
+}
@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/golang-jwt/jwt/v5"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// JWT validates bearer tokens from a single sign on provider, it is nil unless one has been configured
+	JWT *JWTValidator
+
+	// ErrInvalidToken is returned for tokens that can't be trusted, the wrapped error says why
+	ErrInvalidToken = errors.New("invalid token")
+
+	// minRefetchInterval stops tokens with unknown key ids from making us fetch the key set on every request
+	minRefetchInterval = time.Minute
+)
+
+// JWTConfig is how tokens are checked and turned into a principal
+type JWTConfig struct {
+	// Issuer and Audience must match the token's iss and aud claims
+	Issuer   string
+	Audience string
+	// The signing keys are read from a JSON Web Key Set, either a local file or a URL
+	JWKSFile string
+	JWKSURL  string
+	// RefreshInterval is how often keys are fetched again from JWKSURL
+	RefreshInterval time.Duration
+	// RoleClaim is the claim the caller's roles are read from, it can be a string or a list of strings
+	RoleClaim string
+	// RoleMapping turns the values of the role claim into roles, if it is empty the values must be role names
+	RoleMapping map[string]Role
+}
+
+// JWTConfigFromEnv reads the configuration from the environment, ok is false when no key set has been configured
+//
+//	GUESTLIST_JWT_ISSUER, GUESTLIST_JWT_AUDIENCE, GUESTLIST_JWT_JWKS_FILE or GUESTLIST_JWT_JWKS_URL,
+//	GUESTLIST_JWT_ROLE_CLAIM (default roles), GUESTLIST_JWT_ROLE_MAPPING e.g. "sso-admins=admin,sso-door=door_staff"
+func JWTConfigFromEnv() (JWTConfig, bool, error) {
+	cfg := JWTConfig{
+		Issuer:          os.Getenv("GUESTLIST_JWT_ISSUER"),
+		Audience:        os.Getenv("GUESTLIST_JWT_AUDIENCE"),
+		JWKSFile:        os.Getenv("GUESTLIST_JWT_JWKS_FILE"),
+		JWKSURL:         os.Getenv("GUESTLIST_JWT_JWKS_URL"),
+		RefreshInterval: time.Hour,
+		RoleClaim:       os.Getenv("GUESTLIST_JWT_ROLE_CLAIM"),
+		RoleMapping:     map[string]Role{},
+	}
+	if cfg.JWKSFile == "" && cfg.JWKSURL == "" {
+		return cfg, false, nil
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "roles"
+	}
+
+	if mapping := os.Getenv("GUESTLIST_JWT_ROLE_MAPPING"); mapping != "" {
+		for _, pair := range strings.Split(mapping, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 || !IsRole(strings.TrimSpace(parts[1])) {
+				return cfg, false, fmt.Errorf("invalid GUESTLIST_JWT_ROLE_MAPPING entry %q, must be claim=role", pair)
+			}
+			cfg.RoleMapping[strings.TrimSpace(parts[0])] = Role(strings.TrimSpace(parts[1]))
+		}
+	}
+	return cfg, true, nil
+}
+
+// JWTValidator checks the signature and claims of tokens against a key set
+type JWTValidator struct {
+	cfg    JWTConfig
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWTValidator creates a validator and loads its keys, the issuer and audience are required so tokens meant for
+// other services are never accepted
+func NewJWTValidator(cfg JWTConfig) (*JWTValidator, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("a JWT issuer and audience are required")
+	}
+	if (cfg.JWKSFile == "") == (cfg.JWKSURL == "") {
+		return nil, errors.New("exactly one of a JWKS file or URL is required")
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "roles"
+	}
+
+	v := &JWTValidator{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+	if err := v.loadKeys(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Validate checks a token and returns who it was issued to, with the most powerful role found in its role claim
+func (v *JWTValidator) Validate(token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, v.key,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(v.cfg.Issuer),
+		jwt.WithAudience(v.cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidToken)
+	}
+
+	role, ok := v.role(claims[v.cfg.RoleClaim])
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: token has no known role in its %s claim", ErrInvalidToken, v.cfg.RoleClaim)
+	}
+	return Principal{Name: subject, Role: role, Method: "jwt"}, nil
+}
+
+// role picks the most powerful role out of the role claim, which may be a single string or a list
+func (v *JWTValidator) role(claim interface{}) (Role, bool) {
+	var values []string
+	switch c := claim.(type) {
+	case string:
+		values = strings.Fields(c)
+	case []interface{}:
+		for _, value := range c {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	found := map[Role]bool{}
+	for _, value := range values {
+		if len(v.cfg.RoleMapping) > 0 {
+			if role, ok := v.cfg.RoleMapping[value]; ok {
+				found[role] = true
+			}
+		} else if IsRole(value) {
+			found[Role(value)] = true
+		}
+	}
+	for _, role := range Roles {
+		if found[role] {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// key finds the key a token was signed with by its kid, fetching the key set again if the kid is new or the keys are
+// due to be refreshed
+func (v *JWTValidator) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	stale := v.cfg.JWKSURL != "" && time.Since(v.fetchedAt) > v.cfg.RefreshInterval
+	canRefetch := v.cfg.JWKSURL != "" && time.Since(v.fetchedAt) > minRefetchInterval
+	v.mu.RUnlock()
+
+	if stale || (!ok && canRefetch) {
+		if err := v.loadKeys(); err == nil {
+			v.mu.RLock()
+			key, ok = v.keys[kid]
+			v.mu.RUnlock()
+		} else if !ok {
+			return nil, err
+		} else {
+			// Keep using the keys we have until the key set can be fetched again
+			logging.Log.WithError(err).Warn("failed to refresh JWKS, using the keys already loaded")
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// loadKeys reads the key set from the configured file or URL
+func (v *JWTValidator) loadKeys() error {
+	var data []byte
+	var err error
+	if v.cfg.JWKSFile != "" {
+		data, err = ioutil.ReadFile(v.cfg.JWKSFile)
+	} else {
+		data, err = v.fetch(v.cfg.JWKSURL)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS: %w", err)
+	}
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWTValidator) fetch(url string) ([]byte, error) {
+	res, err := v.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with %d", url, res.StatusCode)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS reads the RSA and EC signing keys out of a JSON Web Key Set, keyed by their kid. Other keys are skipped
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RSA key %q: %w", k.Kid, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RSA key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EC key %q: %w", k.Kid, err)
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EC key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "guest-list"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func jwks(t *testing.T, keys ...map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+func writeJWKS(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "alice@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"door_staff"},
+	}
+}
+
+func TestJWTValidate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	v, err := NewJWTValidator(JWTConfig{
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+		JWKSFile:  writeJWKS(t, jwks(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey))),
+		RoleClaim: "roles",
+	})
+	require.NoError(t, err)
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := validClaims()
+		for k, v := range changes {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+
+	cases := []struct {
+		name         string
+		token        string
+		expectedRole Role
+		expectedErr  bool
+	}{
+		{"rsa", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims()), RoleDoorStaff, false},
+		{"ec", sign(t, jwt.SigningMethodES256, "ec", ecKey, validClaims()), RoleDoorStaff, false},
+		{"roleString", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"roles": "planner"})), RolePlanner, false},
+		{"mostPowerfulRole", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"roles": []string{"read_only", "admin", "door_staff"}})), RoleAdmin, false},
+		{"expiredWithinLeeway", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"exp": time.Now().Add(-10 * time.Second).Unix()})), RoleDoorStaff, false},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})), "", true},
+		{"noExpiry", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"exp": nil})), "", true},
+		{"wrongIssuer", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"iss": "https://evil.example.com"})), "", true},
+		{"wrongAudience", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"aud": "someone-else"})), "", true},
+		{"noSubject", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"sub": nil})), "", true},
+		{"noRole", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"roles": nil})), "", true},
+		{"unknownRole", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"roles": []string{"superuser"}})), "", true},
+		{"unknownKeyID", sign(t, jwt.SigningMethodRS256, "other", otherKey, validClaims()), "", true},
+		{"badSignature", sign(t, jwt.SigningMethodRS256, "rsa", otherKey, validClaims()), "", true},
+		{"symmetricAlgorithm", sign(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), validClaims()), "", true},
+		{"notAToken", "not.a.token", "", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := v.Validate(c.token)
+			if c.expectedErr {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, Principal{Name: "alice@example.com", Role: c.expectedRole, Method: "jwt"}, p)
+		})
+	}
+}
+
+func TestJWTRoleMapping(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	v, err := NewJWTValidator(JWTConfig{
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		JWKSFile:    writeJWKS(t, jwks(t, rsaJWK("rsa", &key.PublicKey))),
+		RoleClaim:   "groups",
+		RoleMapping: map[string]Role{"event-door": RoleDoorStaff, "event-planners": RolePlanner},
+	})
+	require.NoError(t, err)
+
+	claims := validClaims()
+	claims["groups"] = []string{"staff", "event-door", "event-planners"}
+	p, err := v.Validate(sign(t, jwt.SigningMethodRS256, "rsa", key, claims))
+	require.NoError(t, err)
+	assert.Equal(t, RolePlanner, p.Role)
+
+	// With a mapping the role names themselves aren't accepted
+	claims["groups"] = []string{"admin"}
+	_, err = v.Validate(sign(t, jwt.SigningMethodRS256, "rsa", key, claims))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestJWTKeysFromURL(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	served := jwks(t, rsaJWK("first", &first.PublicKey))
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = w.Write(served)
+	}))
+	defer server.Close()
+
+	v, err := NewJWTValidator(JWTConfig{Issuer: testIssuer, Audience: testAudience, JWKSURL: server.URL, RefreshInterval: time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+
+	_, err = v.Validate(sign(t, jwt.SigningMethodRS256, "first", first, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+
+	// A key the provider has rotated to is picked up by fetching the key set again
+	served = jwks(t, rsaJWK("first", &first.PublicKey), rsaJWK("second", &second.PublicKey))
+	minRefetchInterval = 0
+	defer func() { minRefetchInterval = time.Minute }()
+
+	_, err = v.Validate(sign(t, jwt.SigningMethodRS256, "second", second, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestNewJWTValidator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	file := writeJWKS(t, jwks(t, rsaJWK("rsa", &key.PublicKey)))
+
+	cases := []struct {
+		name string
+		cfg  JWTConfig
+	}{
+		{"noIssuer", JWTConfig{Audience: testAudience, JWKSFile: file}},
+		{"noAudience", JWTConfig{Issuer: testIssuer, JWKSFile: file}},
+		{"noKeys", JWTConfig{Issuer: testIssuer, Audience: testAudience}},
+		{"fileAndURL", JWTConfig{Issuer: testIssuer, Audience: testAudience, JWKSFile: file, JWKSURL: "http://localhost"}},
+		{"missingFile", JWTConfig{Issuer: testIssuer, Audience: testAudience, JWKSFile: filepath.Join(t.TempDir(), "missing.json")}},
+		{"noUsableKeys", JWTConfig{Issuer: testIssuer, Audience: testAudience, JWKSFile: writeJWKS(t, []byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`))}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewJWTValidator(c.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJWTConfigFromEnv(t *testing.T) {
+	_, ok, err := JWTConfigFromEnv()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Setenv("GUESTLIST_JWT_ISSUER", testIssuer)
+	t.Setenv("GUESTLIST_JWT_AUDIENCE", testAudience)
+	t.Setenv("GUESTLIST_JWT_JWKS_FILE", "/etc/guestlist/jwks.json")
+	t.Setenv("GUESTLIST_JWT_ROLE_MAPPING", "event-admins=admin, event-door=door_staff")
+
+	cfg, ok, err := JWTConfigFromEnv()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "roles", cfg.RoleClaim)
+	assert.Equal(t, map[string]Role{"event-admins": RoleAdmin, "event-door": RoleDoorStaff}, cfg.RoleMapping)
+
+	t.Setenv("GUESTLIST_JWT_ROLE_MAPPING", "event-admins=superuser")
+	_, _, err = JWTConfigFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
+	"gorm.io/gorm"
+	"time"
+)
+
+// SchemaVersion is the version of the document written by Export, it must be bumped whenever the document changes
+// in a way older versions of the service couldn't restore
+const SchemaVersion = 1
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported backup schema version")
+	ErrNotEmpty           = errors.New("database already contains tables or reservations, including deleted reservations")
+)
+
+// Document is a full snapshot of an event
+type Document struct {
+	SchemaVersion int           `json:"schema_version"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Tables        []Table       `json:"tables"`
+	Reservations  []Reservation `json:"reservations"`
+}
+
+type Table struct {
+	Number int `json:"number"`
+	Seats  int `json:"seats"`
+}
+
+type Reservation struct {
+	Guest              string     `json:"name"`
+	TableNumber        int        `json:"table"`
+	AccompanyingGuests int        `json:"accompanying_guests"`
+	ArrivalTime        *time.Time `json:"arrival_time"`
+}
+
+// Export snapshots every table and reservation, including who has arrived and when
+func Export(db *gorm.DB) (Document, error) {
+	doc := Document{
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+		Tables:        []Table{},
+		Reservations:  []Reservation{},
+	}
+
+	var tables []model.Table
+	if err := db.Order("number").Find(&tables).Error; err != nil {
+		return doc, err
+	}
+	for _, t := range tables {
+		doc.Tables = append(doc.Tables, Table{Number: t.Number, Seats: t.Seats})
+	}
+
+	var reservations []model.Reservation
+	if err := db.Preload("Table").Order("guest").Find(&reservations).Error; err != nil {
+		return doc, err
+	}
+	for _, r := range reservations {
+		doc.Reservations = append(doc.Reservations, Reservation{
+			Guest:              r.Guest,
+			TableNumber:        r.Table.Number,
+			AccompanyingGuests: r.AccompanyingGuests,
+			ArrivalTime:        r.ArrivalTime,
+		})
+	}
+	return doc, nil
+}
+
+// Check makes sure a document can be restored by this version of the service and is consistent with itself. Tables
+// and reservations are checked with the same rules the service package applies when they are created, and every table
+// must have room for the reservations on it
+func Check(doc Document) error {
+	if doc.SchemaVersion < 1 || doc.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("%w: got %d, expected at most %d", ErrUnsupportedVersion, doc.SchemaVersion, SchemaVersion)
+	}
+
+	freeSeats := map[int]int{}
+	for _, t := range doc.Tables {
+		if _, ok := freeSeats[t.Number]; ok {
+			return fmt.Errorf("table %d appears more than once", t.Number)
+		}
+		if err := service.ValidateTable(service.NewTable{Number: t.Number, Seats: t.Seats}); err != nil {
+			return fmt.Errorf("table %d: %w", t.Number, err)
+		}
+		freeSeats[t.Number] = t.Seats
+	}
+
+	guests := map[string]bool{}
+	for _, r := range doc.Reservations {
+		err := service.ValidateReservation(service.NewReservation{
+			Guest:              r.Guest,
+			TableNumber:        r.TableNumber,
+			AccompanyingGuests: r.AccompanyingGuests,
+		})
+		if err != nil {
+			return fmt.Errorf("reservation for %q: %w", r.Guest, err)
+		}
+		if guests[r.Guest] {
+			return fmt.Errorf("guest %s appears more than once", r.Guest)
+		}
+		guests[r.Guest] = true
+
+		free, ok := freeSeats[r.TableNumber]
+		if !ok {
+			return fmt.Errorf("guest %s is on table %d which does not exist", r.Guest, r.TableNumber)
+		}
+		needed := r.AccompanyingGuests + 1 // Plus one for the primary guest
+		if needed > free {
+			return fmt.Errorf("guest %s needs %d seats but table %d only has %d left", r.Guest, needed, r.TableNumber, free)
+		}
+		freeSeats[r.TableNumber] = free - needed
+	}
+	return nil
+}
+
+// Restore recreates a snapshot in one transaction, the database must not already contain any tables or reservations,
+// including reservations that have been deleted but not purged yet
+func Restore(db *gorm.DB, doc Document) error {
+	if err := Check(doc); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var tableCount, reservationCount int64
+		if err := tx.Model(&model.Table{}).Count(&tableCount).Error; err != nil {
+			return err
+		}
+		// Deleted reservations that haven't been purged yet still hold on to their guest's name
+		if err := tx.Unscoped().Model(&model.Reservation{}).Count(&reservationCount).Error; err != nil {
+			return err
+		}
+		if tableCount > 0 || reservationCount > 0 {
+			return ErrNotEmpty
+		}
+
+		tables := map[int]model.Table{}
+		for _, t := range doc.Tables {
+			table := model.Table{Number: t.Number, Seats: t.Seats}
+			if err := tx.Create(&table).Error; err != nil {
+				return fmt.Errorf("failed to restore table %d: %w", t.Number, err)
+			}
+			if err := audit.Table(tx, audit.ActionRestore, nil, &table); err != nil {
+				return err
+			}
+			tables[t.Number] = table
+		}
+
+		for _, r := range doc.Reservations {
+			reservation := model.Reservation{
+				Guest:              r.Guest,
+				AccompanyingGuests: r.AccompanyingGuests,
+				Table:              tables[r.TableNumber],
+				ArrivalTime:        r.ArrivalTime,
+			}
+			if err := tx.Create(&reservation).Error; err != nil {
+				return fmt.Errorf("failed to restore reservation for %s: %w", r.Guest, err)
+			}
+			if err := audit.Reservation(tx, audit.ActionRestore, nil, &reservation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name        string
+		doc         Document
+		expectedErr string
+	}{
+		{
+			"good",
+			Document{SchemaVersion: SchemaVersion, Tables: []Table{{1, 5}}, Reservations: []Reservation{{Guest: "bob", TableNumber: 1}}},
+			"",
+		},
+		{
+			"newerVersion",
+			Document{SchemaVersion: SchemaVersion + 1},
+			"unsupported backup schema version: got 2, expected at most 1",
+		},
+		{
+			"missingVersion",
+			Document{},
+			"unsupported backup schema version: got 0, expected at most 1",
+		},
+		{
+			"duplicateTable",
+			Document{SchemaVersion: SchemaVersion, Tables: []Table{{1, 5}, {1, 2}}},
+			"table 1 appears more than once",
+		},
+		{
+			"unknownTable",
+			Document{SchemaVersion: SchemaVersion, Reservations: []Reservation{{Guest: "bob", TableNumber: 1}}},
+			"guest bob is on table 1 which does not exist",
+		},
+		{
+			"tableNumber",
+			Document{SchemaVersion: SchemaVersion, Tables: []Table{{0, 5}}},
+			"table 0: table number must be positive",
+		},
+		{
+			"negativeSeats",
+			Document{SchemaVersion: SchemaVersion, Tables: []Table{{1, -1}}},
+			"table 1: seats can't be negative",
+		},
+		{
+			"missingGuest",
+			Document{SchemaVersion: SchemaVersion, Tables: []Table{{1, 5}}, Reservations: []Reservation{{TableNumber: 1}}},
+			`reservation for "": guest name is required`,
+		},
+		{
+			"negativeGuests",
+			Document{SchemaVersion: SchemaVersion, Tables: []Table{{1, 5}}, Reservations: []Reservation{{Guest: "bob", TableNumber: 1, AccompanyingGuests: -1}}},
+			`reservation for "bob": accompanying guests can't be negative`,
+		},
+		{
+			"overCapacity",
+			Document{SchemaVersion: SchemaVersion, Tables: []Table{{1, 3}}, Reservations: []Reservation{
+				{Guest: "bob", TableNumber: 1, AccompanyingGuests: 1},
+				{Guest: "alice", TableNumber: 1, AccompanyingGuests: 1},
+			}},
+			"guest alice needs 2 seats but table 1 only has 1 left",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Check(c.doc)
+			if c.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, c.expectedErr)
+		})
+	}
+
+	assert.True(t, errors.Is(Check(Document{}), ErrUnsupportedVersion))
+}
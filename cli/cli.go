@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const usage = `Usage: guestlist [-server url] [-api-key key] [-json] <command> [arguments]
+
+Commands:
+  serve [-fixture file|demo]              start the API server, loading the fixture if the database is empty
+  migrate                                 create or update the database tables
+  fixtures load <file|demo>               create the tables, reservations and arrivals in a YAML or JSON fixture
+  tables add <number> <seats>             add a table
+  tables list                             list tables with their reserved, arrived and free seats
+  tables rm <number>                      delete a table
+  reservations add [-guests n] <name> <table>
+                                          reserve seats for a guest and their accompanying guests
+  reservations list [-table n] [-arrived true|false]
+                                          list reservations
+  reservations rm <name>                  cancel a reservation
+  checkin [-guests n] [-undo] <name>      record a guest arriving, or undo it
+  import [-dry-run] [-tables file] [-guest-list file]
+                                          import tables and reservations from CSV files
+  export [-format csv|xlsx] [-o file] <reservations|arrivals|seating>
+                                          download a spreadsheet
+  seats                                   count the seats at the party
+
+Without -server (or GUESTLIST_URL) commands work on the database directly, configured with the same GUESTLIST_DB_*
+variables as the server, and are recorded in the audit log as cli:<user>. With it they are sent to a running server
+using -api-key (or GUESTLIST_API_KEY).
+`
+
+// errUsage is returned when the command line is wrong, usage has been printed
+var errUsage = errors.New("usage")
+
+// env holds what every command needs
+type env struct {
+	stdout     io.Writer
+	stderr     io.Writer
+	jsonOutput bool
+	serverURL  string
+	apiKey     string
+	client     *client
+	// db is used instead of the database from the GUESTLIST_DB_* variables when it is set, for tests
+	db *gorm.DB
+}
+
+type command func(e *env, args []string) error
+
+var commands = map[string]command{
+	"serve":        runServe,
+	"migrate":      runMigrate,
+	"fixtures":     runFixtures,
+	"tables":       runTables,
+	"reservations": runReservations,
+	"checkin":      runCheckIn,
+	"import":       runImport,
+	"export":       runExport,
+	"seats":        runSeats,
+}
+
+// Run runs the guestlist command with the arguments after the program name and returns the exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	return run(&env{stdout: stdout, stderr: stderr}, args)
+}
+
+func run(e *env, args []string) int {
+	flags := flag.NewFlagSet("guestlist", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.Usage = func() { fmt.Fprint(e.stderr, usage) }
+	flags.StringVar(&e.serverURL, "server", os.Getenv("GUESTLIST_URL"), "URL of a running server, e.g. http://localhost:8080")
+	flags.StringVar(&e.apiKey, "api-key", os.Getenv("GUESTLIST_API_KEY"), "API key or token for the server")
+	flags.BoolVar(&e.jsonOutput, "json", false, "print the API's JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Fprint(e.stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(e.stderr, "unknown command %q\n\n%s", flags.Arg(0), usage)
+		return 2
+	}
+
+	err := cmd(e, flags.Args()[1:])
+	if e.client != nil && e.serverURL == "" {
+		// Webhook deliveries happen in the background, they have to finish before the process exits
+		webhooks.Wait()
+	}
+	if errors.Is(err, errUsage) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "guestlist: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// connect sets up the client for commands that use the API
+func (e *env) connect() error {
+	if e.serverURL != "" {
+		e.client = newRemoteClient(e.serverURL, e.apiKey)
+		return nil
+	}
+
+	// Only problems are worth showing when the server's handlers run in this process
+	logging.Log.SetOutput(e.stderr)
+	level := os.Getenv("GUESTLIST_LOG_LEVEL")
+	if level == "" {
+		level = "error"
+	}
+	if err := logging.Configure(os.Getenv("GUESTLIST_LOG_FORMAT"), level); err != nil {
+		return err
+	}
+
+	// Webhooks are sent from this process too, so they follow the server's setting
+	if os.Getenv("GUESTLIST_WEBHOOK_ALLOW_PRIVATE") == "true" {
+		webhooks.AllowPrivate = true
+	}
+
+	db, err := e.database()
+	if err != nil {
+		return err
+	}
+	e.client = newDirectClient(db)
+	return nil
+}
+
+// database connects to the database from the GUESTLIST_DB_* variables, unless the env already has one
+func (e *env) database() (*gorm.DB, error) {
+	if e.db == nil {
+		if err := database.Init(); err != nil {
+			return nil, fmt.Errorf("failed to connect to the database: %w", err)
+		}
+		e.db = database.Get()
+	}
+	return e.db, nil
+}
+
+// subcommand runs one of a command's subcommands, such as tables add
+func subcommand(e *env, name string, args []string, subcommands map[string]command) error {
+	names := []string{}
+	for n := range subcommands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	if len(args) == 0 {
+		fmt.Fprintf(e.stderr, "guestlist %s needs a subcommand: %s\n", name, strings.Join(names, ", "))
+		return errUsage
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "unknown subcommand %q, guestlist %s takes %s\n", args[0], name, strings.Join(names, ", "))
+		return errUsage
+	}
+	return cmd(e, args[1:])
+}
+
+// parseFlags parses a command's flags and checks it was given the right number of arguments
+func parseFlags(e *env, flags *flag.FlagSet, args []string, argNames ...string) error {
+	flags.SetOutput(e.stderr)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != len(argNames) {
+		if len(argNames) == 0 {
+			fmt.Fprintf(e.stderr, "guestlist %s doesn't take any arguments\n", flags.Name())
+		} else {
+			fmt.Fprintf(e.stderr, "usage: guestlist %s %s\n", flags.Name(), strings.Join(argNames, " "))
+		}
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// request is what the fake API saw
+type request struct {
+	Method string
+	URL    string
+	Auth   string
+	Body   string
+}
+
+// newFakeAPI serves canned responses for the routes the CLI uses and records every request made to it
+func newFakeAPI(t *testing.T) (*httptest.Server, *[]request) {
+	requests := &[]request{}
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			*requests = append(*requests, request{r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), string(body)})
+			w.Header().Set("Content-Type", "application/json")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	router.HandleFunc("/v2/tables", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tables":[{"number":1,"seats":4,"reserved_seats":3,"arrived_seats":1,"free_seats":1}]}`))
+	}).Methods("GET")
+	router.HandleFunc("/v2/tables", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number":2,"seats":6}`))
+	}).Methods("POST")
+	router.HandleFunc("/v2/tables/{tableNumber}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"status":409,"code":"conflict","detail":"table still has reservations"}`))
+	}).Methods("DELETE")
+	router.HandleFunc("/v2/reservations", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			_, _ = w.Write([]byte(`{"reservations":[{"name":"alice","table":1,"accompanying_guests":0,"arrived_at":null}],"next_cursor":"abc"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"reservations":[{"name":"bob","table":1,"accompanying_guests":2,"arrived_at":"2026-10-19T20:00:00Z"}]}`))
+	}).Methods("GET")
+	router.HandleFunc("/v2/arrivals/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"name":"bob smith","table":1,"accompanying_guests":1,"arrived_at":"2026-10-19T20:00:00Z"}`))
+	}).Methods("PUT")
+	router.HandleFunc("/v2/seats", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"total":10,"reserved":6,"arrived":3,"empty":7,"available":4}`))
+	}).Methods("GET")
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		name             string
+		args             []string
+		expectedCode     int
+		expectedRequests []request
+		expectedStdout   string
+		expectedStderr   string
+	}{
+		{
+			"tablesList", []string{"tables", "list"}, 0,
+			[]request{{"GET", "/v2/tables", "Bearer gl_test", ""}},
+			"TABLE  SEATS  RESERVED  ARRIVED  FREE\n1      4      3         1        1\n", "",
+		},
+		{
+			"tablesAdd", []string{"tables", "add", "2", "6"}, 0,
+			[]request{{"POST", "/v2/tables", "Bearer gl_test", `{"number":2,"seats":6}`}},
+			"added table 2 with 6 seats\n", "",
+		},
+		{
+			"tablesRemoveConflict", []string{"tables", "rm", "1"}, 1,
+			[]request{{"DELETE", "/v2/tables/1", "Bearer gl_test", ""}},
+			"", "guestlist: conflict: table still has reservations\n",
+		},
+		{
+			"reservationsListEveryPage", []string{"reservations", "list", "-table", "1"}, 0,
+			[]request{
+				{"GET", "/v2/reservations?limit=100&table=1", "Bearer gl_test", ""},
+				{"GET", "/v2/reservations?cursor=abc&limit=100&table=1", "Bearer gl_test", ""},
+			},
+			"NAME   TABLE  GUESTS  ARRIVED\nalice  1      0       -\nbob    1      2       2026-10-19T20:00:00Z\n", "",
+		},
+		{
+			"checkIn", []string{"checkin", "bob smith"}, 0,
+			[]request{{"PUT", "/v2/arrivals/bob%20smith", "Bearer gl_test", `{}`}},
+			"checked in bob smith with 1 accompanying guests at table 1\n", "",
+		},
+		{
+			"checkInWithGuests", []string{"checkin", "-guests", "1", "bob smith"}, 0,
+			[]request{{"PUT", "/v2/arrivals/bob%20smith", "Bearer gl_test", `{"accompanying_guests":1}`}},
+			"checked in bob smith with 1 accompanying guests at table 1\n", "",
+		},
+		{
+			"seatsJSON", []string{"-json", "seats"}, 0,
+			[]request{{"GET", "/v2/seats", "Bearer gl_test", ""}},
+			"{\n  \"total\": 10,\n  \"reserved\": 6,\n  \"arrived\": 3,\n  \"empty\": 7,\n  \"available\": 4\n}\n", "",
+		},
+		{"unknownCommand", []string{"dance"}, 2, nil, "", `unknown command "dance"`},
+		{"missingArgument", []string{"tables", "add", "2"}, 2, nil, "", "usage: guestlist tables add <number> <seats>\n"},
+		{"badNumber", []string{"tables", "rm", "one"}, 1, nil, "", "guestlist: invalid table number \"one\"\n"},
+		{"migrateRemotely", []string{"migrate"}, 1, nil, "", "guestlist: migrate works on the database directly, it can't be used with -server\n"},
+		{"fixturesLoadRemotely", []string{"fixtures", "load", "demo"}, 1, nil, "", "guestlist: fixtures load works on the database directly, it can't be used with -server\n"},
+		{"fixturesWithoutSubcommand", []string{"fixtures"}, 2, nil, "", "guestlist fixtures needs a subcommand: load\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv, requests := newFakeAPI(t)
+
+			var stdout, stderr bytes.Buffer
+			code := Run(append([]string{"-server", srv.URL, "-api-key", "gl_test"}, c.args...), &stdout, &stderr)
+
+			assert.Equal(t, c.expectedCode, code, stderr.String())
+			if c.expectedRequests == nil {
+				assert.Empty(t, *requests)
+			} else {
+				assert.Equal(t, c.expectedRequests, *requests)
+			}
+			assert.Equal(t, c.expectedStdout, stdout.String())
+			assert.True(t, strings.HasPrefix(stderr.String(), c.expectedStderr), stderr.String())
+		})
+	}
+}
+
+func TestRunDirect(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	direct := func(args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		code := run(&env{stdout: &stdout, stderr: &stderr, db: db}, args)
+		return stdout.String(), stderr.String(), code
+	}
+
+	for _, args := range [][]string{
+		{"tables", "add", "1", "4"},
+		{"reservations", "add", "-guests", "1", "bob", "1"},
+		{"checkin", "bob"},
+	} {
+		_, stderr, code := direct(args...)
+		require.Equal(t, 0, code, stderr)
+	}
+
+	stdout, stderr, code := direct("-json", "seats")
+	require.Equal(t, 0, code, stderr)
+	var res seats
+	require.NoError(t, json.Unmarshal([]byte(stdout), &res))
+	assert.Equal(t, seats{Total: 4, Reserved: 2, Arrived: 2, Empty: 2, Available: 2}, res)
+
+	// Changes made directly are still audited
+	entries, err := audit.Search(db, audit.Filter{Guest: "bob"})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.True(t, strings.HasPrefix(entries[0].Actor, "cli"))
+	assert.Equal(t, "cli", entries[0].AuthMethod)
+}
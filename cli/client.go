@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/server"
+	"gorm.io/gorm"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// client makes API requests, either to a running server or straight to the router in this process. Both go through
+// the same handlers, so the rules are the same whichever is used
+type client struct {
+	do func(r *http.Request) (*http.Response, error)
+}
+
+// Error is a problem returned by the API
+type Error struct {
+	Status  int
+	Problem apierror.Problem
+}
+
+func (e *Error) Error() string {
+	if e.Problem.Detail != "" {
+		return fmt.Sprintf("%s: %s", e.Problem.Code, e.Problem.Detail)
+	}
+	return fmt.Sprintf("request failed with status %d", e.Status)
+}
+
+// newRemoteClient sends requests to the server at baseURL with the API key or token
+func newRemoteClient(baseURL, apiKey string) *client {
+	httpClient := &http.Client{Timeout: 60 * time.Second}
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &client{do: func(r *http.Request) (*http.Response, error) {
+		u, err := r.URL.Parse(baseURL + r.URL.RequestURI())
+		if err != nil {
+			return nil, err
+		}
+		r.URL = u
+		r.Host = u.Host
+		if apiKey != "" {
+			r.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		return httpClient.Do(r)
+	}}
+}
+
+// newDirectClient serves requests in this process using db, as an admin recorded in the audit log as cli:<user>
+func newDirectClient(db *gorm.DB) *client {
+	principal := cliPrincipal()
+	router := server.NewRouter()
+
+	return &client{do: func(r *http.Request) (*http.Response, error) {
+		ctx := auth.WithPrincipal(database.WithDB(r.Context(), db), principal)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r.WithContext(ctx))
+		return rec.Result(), nil
+	}}
+}
+
+// cliPrincipal is who changes made directly on the database are made by, an admin named after the user running the
+// command
+func cliPrincipal() auth.Principal {
+	name := "cli"
+	if u, err := user.Current(); err == nil {
+		name = "cli:" + u.Username
+	}
+	return auth.Principal{Name: name, Role: auth.RoleAdmin, Method: "cli"}
+}
+
+// send makes a request and returns the response if it succeeded, problems are returned as an *Error
+func (c *client) send(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 300 {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+	apiErr := &Error{Status: res.StatusCode}
+	out, _ := ioutil.ReadAll(res.Body)
+	_ = json.Unmarshal(out, &apiErr.Problem)
+	return nil, apiErr
+}
+
+// call sends in as JSON, if it isn't nil, and decodes the response into out, if it isn't nil
+func (c *client) call(method, path string, in, out interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+		contentType = "application/json"
+	}
+
+	res, err := c.send(method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
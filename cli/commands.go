@@ -0,0 +1,466 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/exporter"
+	"github.com/ctompkinson/guest-list/fixtures"
+	"github.com/ctompkinson/guest-list/importer"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/server"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+)
+
+// reservation is a reservation as the v2 API returns it
+type reservation struct {
+	Name               string  `json:"name"`
+	TableNumber        int     `json:"table"`
+	AccompanyingGuests int     `json:"accompanying_guests"`
+	ArrivedAt          *string `json:"arrived_at"`
+}
+
+type seats struct {
+	Total     int `json:"total"`
+	Reserved  int `json:"reserved"`
+	Arrived   int `json:"arrived"`
+	Empty     int `json:"empty"`
+	Available int `json:"available"`
+}
+
+func runServe(e *env, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fixture := flags.String("fixture", "", "YAML or JSON fixture to load into an empty database, or demo for a small example party")
+	if err := parseFlags(e, flags, args); err != nil {
+		return err
+	}
+
+	var opts server.Options
+	if *fixture != "" {
+		f, err := fixtures.ReadFile(*fixture)
+		if err != nil {
+			return err
+		}
+		opts.Fixture = &f
+	}
+	server.Start(opts)
+	return nil
+}
+
+func runMigrate(e *env, args []string) error {
+	if err := parseFlags(e, flag.NewFlagSet("migrate", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	if e.serverURL != "" {
+		return errors.New("migrate works on the database directly, it can't be used with -server")
+	}
+	// The database is migrated as soon as it is connected to
+	if _, err := e.database(); err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stdout, "database is up to date")
+	return nil
+}
+
+func runFixtures(e *env, args []string) error {
+	return subcommand(e, "fixtures", args, map[string]command{
+		"load": runFixturesLoad,
+	})
+}
+
+func runFixturesLoad(e *env, args []string) error {
+	flags := flag.NewFlagSet("fixtures load", flag.ContinueOnError)
+	if err := parseFlags(e, flags, args, "<file|demo>"); err != nil {
+		return err
+	}
+	if e.serverURL != "" {
+		return errors.New("fixtures load works on the database directly, it can't be used with -server")
+	}
+	f, err := fixtures.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	db, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), cliPrincipal())
+	if err := fixtures.Load(db.WithContext(ctx), f); err != nil {
+		return err
+	}
+	return e.print(f, func(w io.Writer) {
+		fmt.Fprintf(w, "loaded %d tables, %d reservations and %d arrivals\n", len(f.Tables), len(f.Reservations), len(f.Arrivals))
+	})
+}
+
+func runTables(e *env, args []string) error {
+	return subcommand(e, "tables", args, map[string]command{
+		"add":  runTablesAdd,
+		"list": runTablesList,
+		"rm":   runTablesRemove,
+	})
+}
+
+func runTablesAdd(e *env, args []string) error {
+	flags := flag.NewFlagSet("tables add", flag.ContinueOnError)
+	if err := parseFlags(e, flags, args, "<number>", "<seats>"); err != nil {
+		return err
+	}
+	number, err := parseInt("table number", flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	seats, err := parseInt("seats", flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	var table model.FormattedTable
+	if err := e.client.call("POST", "/v2/tables", map[string]int{"number": number, "seats": seats}, &table); err != nil {
+		return err
+	}
+	return e.print(table, func(w io.Writer) {
+		fmt.Fprintf(w, "added table %d with %d seats\n", table.Number, table.Seats)
+	})
+}
+
+func runTablesList(e *env, args []string) error {
+	if err := parseFlags(e, flag.NewFlagSet("tables list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	var res struct {
+		Tables []model.TableSummary `json:"tables"`
+	}
+	if err := e.client.call("GET", "/v2/tables", nil, &res); err != nil {
+		return err
+	}
+	return e.print(res, func(w io.Writer) {
+		fmt.Fprintln(w, "TABLE\tSEATS\tRESERVED\tARRIVED\tFREE")
+		for _, t := range res.Tables {
+			fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\n", t.Number, t.Seats, t.ReservedSeats, t.ArrivedSeats, t.FreeSeats)
+		}
+	})
+}
+
+func runTablesRemove(e *env, args []string) error {
+	flags := flag.NewFlagSet("tables rm", flag.ContinueOnError)
+	if err := parseFlags(e, flags, args, "<number>"); err != nil {
+		return err
+	}
+	number, err := parseInt("table number", flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	if err := e.client.call("DELETE", fmt.Sprintf("/v2/tables/%d", number), nil, nil); err != nil {
+		return err
+	}
+	return e.print(map[string]int{"deleted": number}, func(w io.Writer) {
+		fmt.Fprintf(w, "deleted table %d\n", number)
+	})
+}
+
+func runReservations(e *env, args []string) error {
+	return subcommand(e, "reservations", args, map[string]command{
+		"add":  runReservationsAdd,
+		"list": runReservationsList,
+		"rm":   runReservationsRemove,
+	})
+}
+
+func runReservationsAdd(e *env, args []string) error {
+	flags := flag.NewFlagSet("reservations add", flag.ContinueOnError)
+	guests := flags.Int("guests", 0, "how many accompanying guests are coming")
+	if err := parseFlags(e, flags, args, "<name>", "<table>"); err != nil {
+		return err
+	}
+	table, err := parseInt("table number", flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	in := map[string]interface{}{"name": flags.Arg(0), "table": table, "accompanying_guests": *guests}
+	var res reservation
+	if err := e.client.call("POST", "/v2/reservations", in, &res); err != nil {
+		return err
+	}
+	return e.print(res, func(w io.Writer) {
+		fmt.Fprintf(w, "reserved %d seats at table %d for %s\n", res.AccompanyingGuests+1, res.TableNumber, res.Name)
+	})
+}
+
+func runReservationsList(e *env, args []string) error {
+	flags := flag.NewFlagSet("reservations list", flag.ContinueOnError)
+	table := flags.String("table", "", "only reservations at this table")
+	arrived := flags.String("arrived", "", "only guests that have (true) or haven't (false) arrived")
+	if err := parseFlags(e, flags, args); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	query := url.Values{"limit": {"100"}}
+	if *table != "" {
+		query.Set("table", *table)
+	}
+	if *arrived != "" {
+		query.Set("arrived", *arrived)
+	}
+
+	// Every page is fetched so the whole list is printed
+	all := struct {
+		Reservations []reservation `json:"reservations"`
+	}{Reservations: []reservation{}}
+	for {
+		var page struct {
+			Reservations []reservation `json:"reservations"`
+			NextCursor   string        `json:"next_cursor"`
+		}
+		if err := e.client.call("GET", "/v2/reservations?"+query.Encode(), nil, &page); err != nil {
+			return err
+		}
+		all.Reservations = append(all.Reservations, page.Reservations...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+
+	return e.print(all, func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tTABLE\tGUESTS\tARRIVED")
+		for _, r := range all.Reservations {
+			arrivedAt := "-"
+			if r.ArrivedAt != nil {
+				arrivedAt = *r.ArrivedAt
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", r.Name, r.TableNumber, r.AccompanyingGuests, arrivedAt)
+		}
+	})
+}
+
+func runReservationsRemove(e *env, args []string) error {
+	flags := flag.NewFlagSet("reservations rm", flag.ContinueOnError)
+	if err := parseFlags(e, flags, args, "<name>"); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	name := flags.Arg(0)
+	if err := e.client.call("DELETE", "/v2/reservations/"+url.PathEscape(name), nil, nil); err != nil {
+		return err
+	}
+	return e.print(map[string]string{"deleted": name}, func(w io.Writer) {
+		fmt.Fprintf(w, "cancelled the reservation for %s\n", name)
+	})
+}
+
+func runCheckIn(e *env, args []string) error {
+	flags := flag.NewFlagSet("checkin", flag.ContinueOnError)
+	guests := flags.Int("guests", 0, "how many accompanying guests came, defaults to the number on the reservation")
+	undo := flags.Bool("undo", false, "undo the guest's arrival")
+	if err := parseFlags(e, flags, args, "<name>"); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	name := flags.Arg(0)
+	path := "/v2/arrivals/" + url.PathEscape(name)
+	if *undo {
+		if err := e.client.call("DELETE", path, nil, nil); err != nil {
+			return err
+		}
+		return e.print(map[string]string{"undone": name}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s is no longer checked in\n", name)
+		})
+	}
+
+	in := map[string]interface{}{}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "guests" {
+			in["accompanying_guests"] = *guests
+		}
+	})
+	var res reservation
+	if err := e.client.call("PUT", path, in, &res); err != nil {
+		return err
+	}
+	return e.print(res, func(w io.Writer) {
+		fmt.Fprintf(w, "checked in %s with %d accompanying guests at table %d\n", res.Name, res.AccompanyingGuests, res.TableNumber)
+	})
+}
+
+func runImport(e *env, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only check the files, don't import anything")
+	tablesFile := flags.String("tables", "", "CSV file of tables with table and seats columns")
+	guestListFile := flags.String("guest-list", "", "CSV file of reservations with name, table and accompanying_guests columns")
+	if err := parseFlags(e, flags, args); err != nil {
+		return err
+	}
+	if *tablesFile == "" && *guestListFile == "" {
+		fmt.Fprintln(e.stderr, "guestlist import needs -tables, -guest-list or both")
+		return errUsage
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for field, path := range map[string]string{importer.TablesFile: *tablesFile, importer.ReservationsFile: *guestListFile} {
+		if path == "" {
+			continue
+		}
+		if err := addFile(form, field, path); err != nil {
+			return err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	path := "/import"
+	if *dryRun {
+		path += "?dry_run=true"
+	}
+	res, err := e.client.send("POST", path, form.FormDataContentType(), &body)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Problem.Errors != nil {
+		// Every row that failed validation is listed rather than just the count
+		var rows []importer.RowError
+		if out, err := json.Marshal(apiErr.Problem.Errors); err == nil && json.Unmarshal(out, &rows) == nil {
+			for _, row := range rows {
+				fmt.Fprintf(e.stderr, "%s line %d: %s\n", row.File, row.Line, row.Message)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var report importer.Report
+	if err := json.NewDecoder(res.Body).Decode(&report); err != nil {
+		return err
+	}
+	return e.print(report, func(w io.Writer) {
+		if report.DryRun {
+			fmt.Fprintln(w, "the files are valid, nothing was imported")
+			return
+		}
+		fmt.Fprintf(w, "imported %d tables and %d reservations\n", report.TablesCreated, report.ReservationsCreated)
+	})
+}
+
+func runExport(e *env, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", exporter.FormatCSV, "csv or xlsx")
+	output := flags.String("o", "", "file to write to instead of standard out")
+	if err := parseFlags(e, flags, args, "<reservations|arrivals|seating>"); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	res, err := e.client.send("GET", fmt.Sprintf("/export/%s?format=%s", url.PathEscape(flags.Arg(0)), url.QueryEscape(*format)), "", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	w := e.stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+func runSeats(e *env, args []string) error {
+	if err := parseFlags(e, flag.NewFlagSet("seats", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	var res seats
+	if err := e.client.call("GET", "/v2/seats", nil, &res); err != nil {
+		return err
+	}
+	return e.print(res, func(w io.Writer) {
+		fmt.Fprintf(w, "total\t%d\nreserved\t%d\narrived\t%d\nempty\t%d\navailable\t%d\n",
+			res.Total, res.Reserved, res.Arrived, res.Empty, res.Available)
+	})
+}
+
+// print writes v as JSON with -json, otherwise it lets text write a table
+func (e *env) print(v interface{}, text func(w io.Writer)) error {
+	if e.jsonOutput {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	text(w)
+	return w.Flush()
+}
+
+func parseInt(what, s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", what, s)
+	}
+	return n, nil
+}
+
+// addFile adds a file to a multipart form
+func addFile(form *multipart.Writer, field, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	part, err := form.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, f)
+	return err
+}
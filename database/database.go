@@ -1,15 +1,25 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/metrics"
 	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/tracing"
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"os"
+	"sync"
+	"time"
 )
 
 var (
+	// mu guards db, the server answers health checks while it connects so db is read and set concurrently
+	mu sync.RWMutex
 	db *gorm.DB
 
 	username     = ""
@@ -21,15 +31,31 @@ var (
 
 // Get returns an instance of the Gorm DB
 func Get() *gorm.DB {
+	mu.RLock()
+	defer mu.RUnlock()
 	return db
 }
 
 // Init initialises the database if it has not already been setup
 func Init() error {
-	if db != nil {
+	if Get() != nil {
 		return nil
 	}
 
+	configure()
+	conn, err := open(databaseName)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	db = conn
+	mu.Unlock()
+
+	return nil
+}
+
+// configure reads the server's address and credentials from the GUESTLIST_DB_* variables
+func configure() {
 	username = os.Getenv("GUESTLIST_DB_USERNAME")
 	if username == "" {
 		username = "root"
@@ -54,63 +80,163 @@ func Init() error {
 	if port == "" {
 		port = "3306"
 	}
+}
 
-	Create()
-	var err error
-	db, err = gorm.Open(mysql.Open(
-		fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", username, password, address, port, databaseName)), &gorm.Config{})
+// open creates the named database if it doesn't exist, connects to it and migrates it
+func open(name string) (*gorm.DB, error) {
+	if err := create(name); err != nil {
+		return nil, err
+	}
+	conn, err := gorm.Open(mysql.Open(
+		fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", username, password, address, port, name)), &gorm.Config{})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := conn.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := conn.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
 	}
 
-	if err := Migrate(); err != nil {
-		return err
+	// The connection is only shared once it is migrated, so a failed attempt can be retried
+	if err := migrate(conn); err != nil {
+		return nil, err
 	}
+	return conn, nil
+}
 
-	return nil
+// Open connects to a separate database called name on the server from the GUESTLIST_DB_* variables, creating and
+// migrating it if needed. Tests use it to get a database each, see the databasetest package
+func Open(name string) (*gorm.DB, error) {
+	configure()
+	return open(name)
 }
 
-// Migrate sets up database tables using gorm models
-func Migrate() error {
-	if err := db.AutoMigrate(&model.Table{}); err != nil {
-		return err
+// Drop deletes the database called name, it is only meant for databases made with Open
+func Drop(name string) error {
+	if name == databaseName {
+		return fmt.Errorf("refusing to drop the server's database %s", name)
 	}
-	if err := db.AutoMigrate(&model.Reservation{}); err != nil {
+	server, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/", username, password, address, port))
+	if err != nil {
 		return err
 	}
-	return nil
+	defer server.Close()
+	_, err = server.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name))
+	return err
 }
 
-// Create creates the database to be used for Gorm
-func Create() {
-	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/", username, password, address, port))
-	if err != nil {
-		panic(err)
+type contextKey struct{}
+
+// WithDB returns a context that makes FromContext return conn instead of the shared connection
+func WithDB(ctx context.Context, conn *gorm.DB) context.Context {
+	return context.WithValue(ctx, contextKey{}, conn)
+}
+
+// FromContext returns the connection added with WithDB, or the shared connection from Get when there isn't one
+func FromContext(ctx context.Context) *gorm.DB {
+	if conn, ok := ctx.Value(contextKey{}).(*gorm.DB); ok {
+		return conn
 	}
-	defer db.Close()
-	_, err = db.Exec("CREATE DATABASE IF NOT EXISTS guestlist")
-	if err != nil {
-		panic(err)
+	return Get()
+}
+
+// Connect calls Init until it succeeds, waiting longer after each failure up to maxBackoff. It gives up once timeout
+// has passed, a timeout of zero keeps trying forever
+func Connect(timeout, maxBackoff time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err := Init()
+		if err == nil {
+			return nil
+		}
+		if timeout > 0 && time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("failed to connect to the database after %d attempts: %w", attempt, err)
+		}
+
+		logging.Log.WithError(err).WithFields(logrus.Fields{"attempt": attempt, "retry_in": backoff.String()}).
+			Warn("failed to connect to the database")
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
 	}
 }
 
-// ClearAndCreate deletes the database and recreates it for testing purposes
-func ClearAndCreate() {
-	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/", username, password, address, port))
-	if err != nil {
-		panic(err)
+// models are every model with a table, in the order they are migrated
+var models = []interface{}{
+	&model.Table{},
+	&model.Reservation{},
+	&model.WebhookSubscription{},
+	&model.WebhookDelivery{},
+	&model.IdempotencyKey{},
+	&model.APIKey{},
+	&model.AuditEntry{},
+}
+
+// Migrate sets up database tables using gorm models
+func Migrate() error {
+	return migrate(Get())
+}
+
+func migrate(conn *gorm.DB) error {
+	for _, m := range models {
+		if err := conn.AutoMigrate(m); err != nil {
+			return err
+		}
 	}
-	defer db.Close()
-	_, err = db.Exec("DROP DATABASE IF EXISTS guestlist")
-	if err != nil {
-		panic(err)
+	return nil
+}
+
+// ErrNotConnected is returned by the checks when Init hasn't succeeded yet
+var ErrNotConnected = errors.New("not connected to the database")
+
+// Ping checks the database can be reached
+func Ping(ctx context.Context) error {
+	conn := FromContext(ctx)
+	if conn == nil {
+		return ErrNotConnected
 	}
-	_, err = db.Exec("CREATE DATABASE IF NOT EXISTS guestlist")
+	sqlDB, err := conn.DB()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return sqlDB.PingContext(ctx)
+}
 
-	if err := Migrate(); err != nil {
-		panic(err)
+// CheckMigrations checks every model's table and columns exist, so the schema isn't behind the code
+func CheckMigrations(ctx context.Context) error {
+	if FromContext(ctx) == nil {
+		return ErrNotConnected
+	}
+	conn := FromContext(ctx).WithContext(ctx)
+	migrator := conn.Migrator()
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: conn}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		if !migrator.HasTable(m) {
+			return fmt.Errorf("table %s has not been migrated", stmt.Schema.Table)
+		}
+		for _, column := range stmt.Schema.DBNames {
+			if !migrator.HasColumn(m, column) {
+				return fmt.Errorf("column %s.%s has not been migrated", stmt.Schema.Table, column)
+			}
+		}
+	}
+	return nil
+}
+
+// create creates the named database for Gorm to connect to
+func create(name string) error {
+	server, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/", username, password, address, port))
+	if err != nil {
+		return err
 	}
+	defer server.Close()
+	_, err = server.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", name))
+	return err
 }
@@ -0,0 +1,46 @@
+// Package databasetest gives each test a database of its own, so tests can run in parallel without clobbering each
+// other or the data in the guestlist database
+package databasetest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/ctompkinson/guest-list/database"
+	"gorm.io/gorm"
+	"testing"
+)
+
+// Prefix starts the name of every test database, anything left behind by an interrupted run can be found with it
+const Prefix = "guestlist_test_"
+
+// New creates an empty, migrated database on the server from the GUESTLIST_DB_* variables and drops it once the test
+// and its subtests have finished
+func New(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("failed to name test database: %v", err)
+	}
+	name := Prefix + hex.EncodeToString(b)
+
+	conn, err := database.Open(name)
+	if err != nil {
+		t.Fatalf("failed to create test database %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		if err := database.Drop(name); err != nil {
+			t.Errorf("failed to drop test database %s: %v", name, err)
+		}
+	})
+	return conn
+}
+
+// Context returns a context that makes database.FromContext, and so every handler, use conn
+func Context(conn *gorm.DB) context.Context {
+	return database.WithDB(context.Background(), conn)
+}
@@ -0,0 +1,262 @@
+package exporter
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	SheetReservations = "reservations"
+	SheetArrivals     = "arrivals"
+	SheetSeating      = "seating"
+
+	timeFormat = "02/01/06 15:04"
+)
+
+var (
+	Formats = []string{FormatCSV, FormatXLSX}
+	Sheets  = []string{SheetReservations, SheetArrivals, SheetSeating}
+
+	contentTypes = map[string]string{
+		FormatCSV:  "text/csv",
+		FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}
+)
+
+// rowWriter writes a single spreadsheet row at a time so large lists never have to be held in memory
+type rowWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// row is a single line of any sheet, columns that don't apply to a sheet are left empty
+type row struct {
+	Guest              sql.NullString
+	TableNumber        sql.NullInt64
+	Seats              sql.NullInt64
+	AccompanyingGuests sql.NullInt64
+	ArrivalTime        *time.Time
+}
+
+// IsFormat checks if the given format can be exported
+func IsFormat(format string) bool {
+	_, ok := contentTypes[format]
+	return ok
+}
+
+// IsSheet checks if the given sheet can be exported
+func IsSheet(sheet string) bool {
+	for _, s := range Sheets {
+		if s == sheet {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType returns the mime type of a format
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Export streams a sheet in the given format to w, rows are read from the database one at a time
+func Export(w io.Writer, db *gorm.DB, sheet, format string) error {
+	if !IsSheet(sheet) {
+		return fmt.Errorf("unknown sheet %s", sheet)
+	}
+
+	var out rowWriter
+	switch format {
+	case FormatCSV:
+		out = newCSVWriter(w)
+	case FormatXLSX:
+		x, err := newXLSXWriter(w, sheet)
+		if err != nil {
+			return err
+		}
+		// Removes the stream writer's temporary files if the export stops early
+		defer x.file.Close()
+		out = x
+	default:
+		return fmt.Errorf("unknown format %s", format)
+	}
+
+	if err := out.WriteRow(header(sheet)); err != nil {
+		return err
+	}
+
+	rows, err := query(db, sheet).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.Guest, &r.TableNumber, &r.Seats, &r.AccompanyingGuests, &r.ArrivalTime); err != nil {
+			return err
+		}
+		if err := out.WriteRow(values(sheet, r)); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// query builds the query for a sheet, every query selects the same columns so they can share a row type
+func query(db *gorm.DB, sheet string) *gorm.DB {
+	columns := "reservations.guest, tables.number, tables.seats, reservations.accompanying_guests, reservations.arrival_time"
+
+	switch sheet {
+	case SheetArrivals:
+		return db.Table("reservations").Select(columns).
+			Joins("LEFT JOIN tables ON tables.id = reservations.table_id").
+			Where("reservations.deleted_at IS NULL AND reservations.arrival_time IS NOT NULL").
+			Order("reservations.arrival_time, reservations.guest")
+	case SheetSeating:
+		// Start from tables so empty tables still show up on the seating plan
+		return db.Table("tables").Select(columns).
+			Joins("LEFT JOIN reservations ON reservations.table_id = tables.id AND reservations.deleted_at IS NULL").
+			Where("tables.deleted_at IS NULL").
+			Order("tables.number, reservations.guest")
+	default:
+		return db.Table("reservations").Select(columns).
+			Joins("LEFT JOIN tables ON tables.id = reservations.table_id").
+			Where("reservations.deleted_at IS NULL").
+			Order("reservations.guest")
+	}
+}
+
+func header(sheet string) []interface{} {
+	switch sheet {
+	case SheetArrivals:
+		return []interface{}{"name", "table", "accompanying_guests", "time_arrived"}
+	case SheetSeating:
+		return []interface{}{"table", "seats", "name", "accompanying_guests", "arrived"}
+	default:
+		return []interface{}{"name", "table", "accompanying_guests"}
+	}
+}
+
+func values(sheet string, r row) []interface{} {
+	switch sheet {
+	case SheetArrivals:
+		return []interface{}{r.Guest.String, r.TableNumber.Int64, r.AccompanyingGuests.Int64, r.ArrivalTime}
+	case SheetSeating:
+		if !r.Guest.Valid {
+			return []interface{}{r.TableNumber.Int64, r.Seats.Int64, nil, nil, nil}
+		}
+		return []interface{}{r.TableNumber.Int64, r.Seats.Int64, r.Guest.String, r.AccompanyingGuests.Int64, r.ArrivalTime != nil}
+	default:
+		return []interface{}{r.Guest.String, r.TableNumber.Int64, r.AccompanyingGuests.Int64}
+	}
+}
+
+// escapeFormula stops a guest's name being run as a formula when the export is opened in a spreadsheet, values that
+// start with a character that begins a formula get a leading quote
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			record[i] = ""
+		case string:
+			record[i] = escapeFormula(v)
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		case *time.Time:
+			if v != nil {
+				record[i] = v.Format(timeFormat)
+			}
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: f, stream: stream}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []interface{}) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			values[i] = escapeFormula(v)
+		case *time.Time:
+			// The stream writer can't handle typed nil pointers, so turn arrival times into plain values
+			if v == nil {
+				values[i] = nil
+			} else {
+				values[i] = *v
+			}
+		}
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Close() error {
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
@@ -0,0 +1,198 @@
+package fixtures
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/model"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"time"
+)
+
+// Fixture describes tables, reservations and arrivals to create. It is written as YAML or JSON:
+//
+//	tables:
+//	  - {number: 1, seats: 8}
+//	reservations:
+//	  - {name: bob, table: 1, accompanying_guests: 2}
+//	arrivals:
+//	  - {name: bob, accompanying_guests: 1, arrived_at: 2026-06-20T18:30:00Z}
+//
+// Capacity isn't checked, so fixtures can set up overbooked tables
+type Fixture struct {
+	Tables       []Table       `yaml:"tables" json:"tables"`
+	Reservations []Reservation `yaml:"reservations" json:"reservations"`
+	Arrivals     []Arrival     `yaml:"arrivals" json:"arrivals"`
+}
+
+type Table struct {
+	Number int `yaml:"number" json:"number"`
+	Seats  int `yaml:"seats" json:"seats"`
+}
+
+type Reservation struct {
+	Name               string `yaml:"name" json:"name"`
+	TableNumber        int    `yaml:"table" json:"table"`
+	AccompanyingGuests int    `yaml:"accompanying_guests" json:"accompanying_guests"`
+}
+
+// Arrival checks in a guest from the fixture or already in the database. AccompanyingGuests defaults to the number on
+// the reservation and ArrivedAt to when the fixture is loaded
+type Arrival struct {
+	Name               string     `yaml:"name" json:"name"`
+	AccompanyingGuests *int       `yaml:"accompanying_guests" json:"accompanying_guests"`
+	ArrivedAt          *time.Time `yaml:"arrived_at" json:"arrived_at"`
+}
+
+// ErrNotEmpty is returned by LoadIfEmpty when there are already tables
+var ErrNotEmpty = errors.New("database already contains tables")
+
+//go:embed demo.yaml
+var demo []byte
+
+// Demo is a small party to try the API with
+func Demo() Fixture {
+	f, err := Parse(bytes.NewReader(demo))
+	if err != nil {
+		panic(fmt.Errorf("invalid demo fixture: %w", err))
+	}
+	return f
+}
+
+// Parse reads a fixture from YAML or JSON, unknown fields are an error so typos don't go unnoticed
+func Parse(r io.Reader) (Fixture, error) {
+	var f Fixture
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return f, fmt.Errorf("invalid fixture: %w", err)
+	}
+	return f, nil
+}
+
+// MustParse parses a fixture from a string and panics if it is invalid, for tests
+func MustParse(s string) Fixture {
+	f, err := Parse(bytes.NewReader([]byte(s)))
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// ReadFile reads a fixture from a YAML or JSON file, or the demo fixture if path is demo
+func ReadFile(path string) (Fixture, error) {
+	if path == "demo" {
+		return Demo(), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Load creates everything in the fixture in one transaction, so either all of it is created or none of it is.
+// Reservations can be on tables from the fixture or already in the database, and arrivals can be for either kind of
+// reservation. Every row is recorded in the audit log
+func Load(db *gorm.DB, f Fixture) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return load(tx, f)
+	})
+}
+
+// LoadIfEmpty loads the fixture only if the database has no tables yet, otherwise it returns ErrNotEmpty
+func LoadIfEmpty(db *gorm.DB, f Fixture) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Table{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrNotEmpty
+		}
+		return load(tx, f)
+	})
+}
+
+func load(tx *gorm.DB, f Fixture) error {
+	for _, t := range f.Tables {
+		table := model.Table{Number: t.Number, Seats: t.Seats}
+		var existing int64
+		if err := tx.Model(&model.Table{}).Where("number = ?", t.Number).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("table %d already exists", t.Number)
+		}
+		if err := tx.Create(&table).Error; err != nil {
+			return fmt.Errorf("failed to create table %d: %w", t.Number, err)
+		}
+		if err := audit.Table(tx, audit.ActionFixture, nil, &table); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range f.Reservations {
+		var table model.Table
+		if err := tx.Where("number = ?", r.TableNumber).First(&table).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("reservation for %s is on table %d, which doesn't exist", r.Name, r.TableNumber)
+			}
+			return err
+		}
+		// Deleted reservations count too, as guest names are unique among them
+		var existing int64
+		if err := tx.Unscoped().Model(&model.Reservation{}).Where("guest = ?", r.Name).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("%s already has a reservation, or a deleted one that hasn't been purged", r.Name)
+		}
+		reservation := model.Reservation{Guest: r.Name, AccompanyingGuests: r.AccompanyingGuests, Table: table}
+		if err := tx.Create(&reservation).Error; err != nil {
+			return fmt.Errorf("failed to create reservation for %s: %w", r.Name, err)
+		}
+		if err := audit.Reservation(tx, audit.ActionFixture, nil, &reservation); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, a := range f.Arrivals {
+		var reservation model.Reservation
+		if err := tx.Preload("Table").Where("guest = ?", a.Name).First(&reservation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%s arrived but doesn't have a reservation", a.Name)
+			}
+			return err
+		}
+		before := reservation
+
+		arrivedAt := now
+		if a.ArrivedAt != nil {
+			arrivedAt = *a.ArrivedAt
+		}
+		values := map[string]interface{}{"arrival_time": arrivedAt, "version": reservation.Version + 1}
+		if a.AccompanyingGuests != nil {
+			values["accompanying_guests"] = *a.AccompanyingGuests
+		}
+		if err := tx.Model(&reservation).Updates(values).Error; err != nil {
+			return fmt.Errorf("failed to check in %s: %w", a.Name, err)
+		}
+		reservation.ArrivalTime = &arrivedAt
+		reservation.Version = before.Version + 1
+		if a.AccompanyingGuests != nil {
+			reservation.AccompanyingGuests = *a.AccompanyingGuests
+		}
+		if err := audit.Reservation(tx, audit.ActionFixture, &before, &reservation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package fixtures
+
+import (
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	one := 1
+	arrivedAt := time.Date(2026, 6, 20, 18, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name          string
+		input         string
+		expected      Fixture
+		expectedError string
+	}{
+		{
+			"yaml",
+			"tables:\n  - {number: 1, seats: 8}\nreservations:\n  - {name: bob, table: 1, accompanying_guests: 2}\n" +
+				"arrivals:\n  - {name: bob, accompanying_guests: 1, arrived_at: 2026-06-20T18:30:00Z}\n",
+			Fixture{
+				Tables:       []Table{{Number: 1, Seats: 8}},
+				Reservations: []Reservation{{Name: "bob", TableNumber: 1, AccompanyingGuests: 2}},
+				Arrivals:     []Arrival{{Name: "bob", AccompanyingGuests: &one, ArrivedAt: &arrivedAt}},
+			},
+			"",
+		},
+		{
+			"json",
+			`{"tables": [{"number": 2, "seats": 4}], "arrivals": [{"name": "alice"}]}`,
+			Fixture{Tables: []Table{{Number: 2, Seats: 4}}, Arrivals: []Arrival{{Name: "alice"}}},
+			"",
+		},
+		{"empty", "", Fixture{}, ""},
+		{"unknownField", "tables:\n  - {number: 1, chairs: 8}\n", Fixture{}, "field chairs not found"},
+		{"wrongType", "tables:\n  - {number: one, seats: 8}\n", Fixture{}, "invalid fixture"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := Parse(strings.NewReader(c.input))
+			if c.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, f)
+		})
+	}
+}
+
+func TestDemo(t *testing.T) {
+	f := Demo()
+	assert.NotEmpty(t, f.Tables)
+	assert.NotEmpty(t, f.Reservations)
+
+	// Every reservation and arrival has to line up for the demo to load
+	tables := map[int]bool{}
+	for _, table := range f.Tables {
+		tables[table.Number] = true
+	}
+	guests := map[string]bool{}
+	for _, r := range f.Reservations {
+		assert.True(t, tables[r.TableNumber], r.Name)
+		guests[r.Name] = true
+	}
+	for _, a := range f.Arrivals {
+		assert.True(t, guests[a.Name], a.Name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+
+	require.NoError(t, Load(db, MustParse(`
+tables:
+  - {number: 1, seats: 4}
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 2}
+  - {name: alice, table: 1}
+arrivals:
+  - {name: bob, accompanying_guests: 1}
+`)))
+
+	var bob model.Reservation
+	require.NoError(t, db.Where("guest = ?", "bob").First(&bob).Error)
+	assert.Equal(t, 1, bob.AccompanyingGuests)
+	assert.NotNil(t, bob.ArrivalTime)
+
+	entries, err := audit.Search(db, audit.Filter{Guest: "bob"})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, audit.ActionFixture, entries[0].Action)
+
+	// Nothing is created when part of the fixture is wrong
+	err = Load(db, MustParse("tables:\n  - {number: 2, seats: 4}\nreservations:\n  - {name: carol, table: 3}\n"))
+	assert.EqualError(t, err, "reservation for carol is on table 3, which doesn't exist")
+	var count int64
+	require.NoError(t, db.Model(&model.Table{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	assert.EqualError(t, Load(db, MustParse("tables:\n  - {number: 1, seats: 4}\n")), "table 1 already exists")
+	assert.ErrorIs(t, LoadIfEmpty(db, Demo()), ErrNotEmpty)
+}
@@ -0,0 +1,1460 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: guestlist.proto
+
+package guestlistpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Shrink is what to do when a table is given fewer seats than are reserved on it
+type Shrink int32
+
+const (
+	// SHRINK_REJECT refuses the change
+	Shrink_SHRINK_REJECT Shrink = 0
+	// SHRINK_FORCE makes the change anyway, leaving the table overbooked
+	Shrink_SHRINK_FORCE Shrink = 1
+	// SHRINK_REBALANCE moves guests that haven't arrived yet to other tables until everyone left fits
+	Shrink_SHRINK_REBALANCE Shrink = 2
+)
+
+// Enum value maps for Shrink.
+var (
+	Shrink_name = map[int32]string{
+		0: "SHRINK_REJECT",
+		1: "SHRINK_FORCE",
+		2: "SHRINK_REBALANCE",
+	}
+	Shrink_value = map[string]int32{
+		"SHRINK_REJECT":    0,
+		"SHRINK_FORCE":     1,
+		"SHRINK_REBALANCE": 2,
+	}
+)
+
+func (x Shrink) Enum() *Shrink {
+	p := new(Shrink)
+	*p = x
+	return p
+}
+
+func (x Shrink) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Shrink) Descriptor() protoreflect.EnumDescriptor {
+	return file_guestlist_proto_enumTypes[0].Descriptor()
+}
+
+func (Shrink) Type() protoreflect.EnumType {
+	return &file_guestlist_proto_enumTypes[0]
+}
+
+func (x Shrink) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Shrink.Descriptor instead.
+func (Shrink) EnumDescriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{0}
+}
+
+type ArrivalEvent_Type int32
+
+const (
+	ArrivalEvent_TYPE_UNSPECIFIED     ArrivalEvent_Type = 0
+	ArrivalEvent_TYPE_ARRIVED         ArrivalEvent_Type = 1
+	ArrivalEvent_TYPE_CHECK_IN_UNDONE ArrivalEvent_Type = 2
+)
+
+// Enum value maps for ArrivalEvent_Type.
+var (
+	ArrivalEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_ARRIVED",
+		2: "TYPE_CHECK_IN_UNDONE",
+	}
+	ArrivalEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED":     0,
+		"TYPE_ARRIVED":         1,
+		"TYPE_CHECK_IN_UNDONE": 2,
+	}
+)
+
+func (x ArrivalEvent_Type) Enum() *ArrivalEvent_Type {
+	p := new(ArrivalEvent_Type)
+	*p = x
+	return p
+}
+
+func (x ArrivalEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ArrivalEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_guestlist_proto_enumTypes[1].Descriptor()
+}
+
+func (ArrivalEvent_Type) Type() protoreflect.EnumType {
+	return &file_guestlist_proto_enumTypes[1]
+}
+
+func (x ArrivalEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ArrivalEvent_Type.Descriptor instead.
+func (ArrivalEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{21, 0}
+}
+
+type Table struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Seats         int32                  `protobuf:"varint,2,opt,name=seats,proto3" json:"seats,omitempty"`
+	Version       uint32                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Table) Reset() {
+	*x = Table{}
+	mi := &file_guestlist_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Table) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Table) ProtoMessage() {}
+
+func (x *Table) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Table.ProtoReflect.Descriptor instead.
+func (*Table) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Table) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Table) GetSeats() int32 {
+	if x != nil {
+		return x.Seats
+	}
+	return 0
+}
+
+func (x *Table) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type TableSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Seats         int32                  `protobuf:"varint,2,opt,name=seats,proto3" json:"seats,omitempty"`
+	ReservedSeats int32                  `protobuf:"varint,3,opt,name=reserved_seats,json=reservedSeats,proto3" json:"reserved_seats,omitempty"`
+	ArrivedSeats  int32                  `protobuf:"varint,4,opt,name=arrived_seats,json=arrivedSeats,proto3" json:"arrived_seats,omitempty"`
+	FreeSeats     int32                  `protobuf:"varint,5,opt,name=free_seats,json=freeSeats,proto3" json:"free_seats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TableSummary) Reset() {
+	*x = TableSummary{}
+	mi := &file_guestlist_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TableSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TableSummary) ProtoMessage() {}
+
+func (x *TableSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TableSummary.ProtoReflect.Descriptor instead.
+func (*TableSummary) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TableSummary) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *TableSummary) GetSeats() int32 {
+	if x != nil {
+		return x.Seats
+	}
+	return 0
+}
+
+func (x *TableSummary) GetReservedSeats() int32 {
+	if x != nil {
+		return x.ReservedSeats
+	}
+	return 0
+}
+
+func (x *TableSummary) GetArrivedSeats() int32 {
+	if x != nil {
+		return x.ArrivedSeats
+	}
+	return 0
+}
+
+func (x *TableSummary) GetFreeSeats() int32 {
+	if x != nil {
+		return x.FreeSeats
+	}
+	return 0
+}
+
+type Reservation struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Table              int32                  `protobuf:"varint,2,opt,name=table,proto3" json:"table,omitempty"`
+	AccompanyingGuests int32                  `protobuf:"varint,3,opt,name=accompanying_guests,json=accompanyingGuests,proto3" json:"accompanying_guests,omitempty"`
+	// arrival_time is only set once the guest has checked in
+	ArrivalTime   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=arrival_time,json=arrivalTime,proto3" json:"arrival_time,omitempty"`
+	Version       uint32                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reservation) Reset() {
+	*x = Reservation{}
+	mi := &file_guestlist_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reservation) ProtoMessage() {}
+
+func (x *Reservation) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reservation.ProtoReflect.Descriptor instead.
+func (*Reservation) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Reservation) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Reservation) GetTable() int32 {
+	if x != nil {
+		return x.Table
+	}
+	return 0
+}
+
+func (x *Reservation) GetAccompanyingGuests() int32 {
+	if x != nil {
+		return x.AccompanyingGuests
+	}
+	return 0
+}
+
+func (x *Reservation) GetArrivalTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ArrivalTime
+	}
+	return nil
+}
+
+func (x *Reservation) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Seats struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Total    int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Reserved int32                  `protobuf:"varint,2,opt,name=reserved,proto3" json:"reserved,omitempty"`
+	Arrived  int32                  `protobuf:"varint,3,opt,name=arrived,proto3" json:"arrived,omitempty"`
+	// empty is every seat not taken by a guest that has arrived
+	Empty int32 `protobuf:"varint,4,opt,name=empty,proto3" json:"empty,omitempty"`
+	// available is every seat that hasn't been reserved
+	Available     int32 `protobuf:"varint,5,opt,name=available,proto3" json:"available,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Seats) Reset() {
+	*x = Seats{}
+	mi := &file_guestlist_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Seats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Seats) ProtoMessage() {}
+
+func (x *Seats) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Seats.ProtoReflect.Descriptor instead.
+func (*Seats) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Seats) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Seats) GetReserved() int32 {
+	if x != nil {
+		return x.Reserved
+	}
+	return 0
+}
+
+func (x *Seats) GetArrived() int32 {
+	if x != nil {
+		return x.Arrived
+	}
+	return 0
+}
+
+func (x *Seats) GetEmpty() int32 {
+	if x != nil {
+		return x.Empty
+	}
+	return 0
+}
+
+func (x *Seats) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+type ListTablesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTablesRequest) Reset() {
+	*x = ListTablesRequest{}
+	mi := &file_guestlist_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTablesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTablesRequest) ProtoMessage() {}
+
+func (x *ListTablesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTablesRequest.ProtoReflect.Descriptor instead.
+func (*ListTablesRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{4}
+}
+
+type ListTablesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tables        []*TableSummary        `protobuf:"bytes,1,rep,name=tables,proto3" json:"tables,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTablesResponse) Reset() {
+	*x = ListTablesResponse{}
+	mi := &file_guestlist_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTablesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTablesResponse) ProtoMessage() {}
+
+func (x *ListTablesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTablesResponse.ProtoReflect.Descriptor instead.
+func (*ListTablesResponse) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListTablesResponse) GetTables() []*TableSummary {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+type GetTableRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTableRequest) Reset() {
+	*x = GetTableRequest{}
+	mi := &file_guestlist_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTableRequest) ProtoMessage() {}
+
+func (x *GetTableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTableRequest.ProtoReflect.Descriptor instead.
+func (*GetTableRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetTableRequest) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+type CreateTableRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Seats         int32                  `protobuf:"varint,2,opt,name=seats,proto3" json:"seats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTableRequest) Reset() {
+	*x = CreateTableRequest{}
+	mi := &file_guestlist_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTableRequest) ProtoMessage() {}
+
+func (x *CreateTableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTableRequest.ProtoReflect.Descriptor instead.
+func (*CreateTableRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateTableRequest) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *CreateTableRequest) GetSeats() int32 {
+	if x != nil {
+		return x.Seats
+	}
+	return 0
+}
+
+type UpdateTableRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	NewNumber     *int32                 `protobuf:"varint,2,opt,name=new_number,json=newNumber,proto3,oneof" json:"new_number,omitempty"`
+	Seats         *int32                 `protobuf:"varint,3,opt,name=seats,proto3,oneof" json:"seats,omitempty"`
+	Shrink        Shrink                 `protobuf:"varint,4,opt,name=shrink,proto3,enum=guestlist.v1.Shrink" json:"shrink,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTableRequest) Reset() {
+	*x = UpdateTableRequest{}
+	mi := &file_guestlist_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTableRequest) ProtoMessage() {}
+
+func (x *UpdateTableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTableRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTableRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateTableRequest) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *UpdateTableRequest) GetNewNumber() int32 {
+	if x != nil && x.NewNumber != nil {
+		return *x.NewNumber
+	}
+	return 0
+}
+
+func (x *UpdateTableRequest) GetSeats() int32 {
+	if x != nil && x.Seats != nil {
+		return *x.Seats
+	}
+	return 0
+}
+
+func (x *UpdateTableRequest) GetShrink() Shrink {
+	if x != nil {
+		return x.Shrink
+	}
+	return Shrink_SHRINK_REJECT
+}
+
+type UpdateTableResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Table *Table                 `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	// moved are the reservations a rebalance moved to other tables
+	Moved         []*Reservation `protobuf:"bytes,2,rep,name=moved,proto3" json:"moved,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTableResponse) Reset() {
+	*x = UpdateTableResponse{}
+	mi := &file_guestlist_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTableResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTableResponse) ProtoMessage() {}
+
+func (x *UpdateTableResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTableResponse.ProtoReflect.Descriptor instead.
+func (*UpdateTableResponse) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateTableResponse) GetTable() *Table {
+	if x != nil {
+		return x.Table
+	}
+	return nil
+}
+
+func (x *UpdateTableResponse) GetMoved() []*Reservation {
+	if x != nil {
+		return x.Moved
+	}
+	return nil
+}
+
+type DeleteTableRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTableRequest) Reset() {
+	*x = DeleteTableRequest{}
+	mi := &file_guestlist_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTableRequest) ProtoMessage() {}
+
+func (x *DeleteTableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTableRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTableRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteTableRequest) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+type ListReservationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReservationsRequest) Reset() {
+	*x = ListReservationsRequest{}
+	mi := &file_guestlist_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReservationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsRequest) ProtoMessage() {}
+
+func (x *ListReservationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsRequest.ProtoReflect.Descriptor instead.
+func (*ListReservationsRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{11}
+}
+
+type ListReservationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reservations  []*Reservation         `protobuf:"bytes,1,rep,name=reservations,proto3" json:"reservations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReservationsResponse) Reset() {
+	*x = ListReservationsResponse{}
+	mi := &file_guestlist_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReservationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsResponse) ProtoMessage() {}
+
+func (x *ListReservationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsResponse.ProtoReflect.Descriptor instead.
+func (*ListReservationsResponse) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListReservationsResponse) GetReservations() []*Reservation {
+	if x != nil {
+		return x.Reservations
+	}
+	return nil
+}
+
+type GetReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReservationRequest) Reset() {
+	*x = GetReservationRequest{}
+	mi := &file_guestlist_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReservationRequest) ProtoMessage() {}
+
+func (x *GetReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReservationRequest.ProtoReflect.Descriptor instead.
+func (*GetReservationRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetReservationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type CreateReservationRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Table              int32                  `protobuf:"varint,2,opt,name=table,proto3" json:"table,omitempty"`
+	AccompanyingGuests int32                  `protobuf:"varint,3,opt,name=accompanying_guests,json=accompanyingGuests,proto3" json:"accompanying_guests,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *CreateReservationRequest) Reset() {
+	*x = CreateReservationRequest{}
+	mi := &file_guestlist_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateReservationRequest) ProtoMessage() {}
+
+func (x *CreateReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateReservationRequest.ProtoReflect.Descriptor instead.
+func (*CreateReservationRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CreateReservationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateReservationRequest) GetTable() int32 {
+	if x != nil {
+		return x.Table
+	}
+	return 0
+}
+
+func (x *CreateReservationRequest) GetAccompanyingGuests() int32 {
+	if x != nil {
+		return x.AccompanyingGuests
+	}
+	return 0
+}
+
+type DeleteReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteReservationRequest) Reset() {
+	*x = DeleteReservationRequest{}
+	mi := &file_guestlist_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReservationRequest) ProtoMessage() {}
+
+func (x *DeleteReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReservationRequest.ProtoReflect.Descriptor instead.
+func (*DeleteReservationRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DeleteReservationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListArrivalsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArrivalsRequest) Reset() {
+	*x = ListArrivalsRequest{}
+	mi := &file_guestlist_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArrivalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArrivalsRequest) ProtoMessage() {}
+
+func (x *ListArrivalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArrivalsRequest.ProtoReflect.Descriptor instead.
+func (*ListArrivalsRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{16}
+}
+
+type CheckInRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	AccompanyingGuests int32                  `protobuf:"varint,2,opt,name=accompanying_guests,json=accompanyingGuests,proto3" json:"accompanying_guests,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *CheckInRequest) Reset() {
+	*x = CheckInRequest{}
+	mi := &file_guestlist_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckInRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckInRequest) ProtoMessage() {}
+
+func (x *CheckInRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckInRequest.ProtoReflect.Descriptor instead.
+func (*CheckInRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CheckInRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CheckInRequest) GetAccompanyingGuests() int32 {
+	if x != nil {
+		return x.AccompanyingGuests
+	}
+	return 0
+}
+
+type UndoCheckInRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndoCheckInRequest) Reset() {
+	*x = UndoCheckInRequest{}
+	mi := &file_guestlist_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndoCheckInRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndoCheckInRequest) ProtoMessage() {}
+
+func (x *UndoCheckInRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndoCheckInRequest.ProtoReflect.Descriptor instead.
+func (*UndoCheckInRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *UndoCheckInRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetSeatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSeatsRequest) Reset() {
+	*x = GetSeatsRequest{}
+	mi := &file_guestlist_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSeatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSeatsRequest) ProtoMessage() {}
+
+func (x *GetSeatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSeatsRequest.ProtoReflect.Descriptor instead.
+func (*GetSeatsRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{19}
+}
+
+type WatchArrivalsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchArrivalsRequest) Reset() {
+	*x = WatchArrivalsRequest{}
+	mi := &file_guestlist_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchArrivalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchArrivalsRequest) ProtoMessage() {}
+
+func (x *WatchArrivalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchArrivalsRequest.ProtoReflect.Descriptor instead.
+func (*WatchArrivalsRequest) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{20}
+}
+
+type ArrivalEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          ArrivalEvent_Type      `protobuf:"varint,1,opt,name=type,proto3,enum=guestlist.v1.ArrivalEvent_Type" json:"type,omitempty"`
+	Reservation   *Reservation           `protobuf:"bytes,2,opt,name=reservation,proto3" json:"reservation,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArrivalEvent) Reset() {
+	*x = ArrivalEvent{}
+	mi := &file_guestlist_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArrivalEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArrivalEvent) ProtoMessage() {}
+
+func (x *ArrivalEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_guestlist_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArrivalEvent.ProtoReflect.Descriptor instead.
+func (*ArrivalEvent) Descriptor() ([]byte, []int) {
+	return file_guestlist_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ArrivalEvent) GetType() ArrivalEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return ArrivalEvent_TYPE_UNSPECIFIED
+}
+
+func (x *ArrivalEvent) GetReservation() *Reservation {
+	if x != nil {
+		return x.Reservation
+	}
+	return nil
+}
+
+func (x *ArrivalEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+var File_guestlist_proto protoreflect.FileDescriptor
+
+const file_guestlist_proto_rawDesc = "" +
+	"\n" +
+	"\x0fguestlist.proto\x12\fguestlist.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"O\n" +
+	"\x05Table\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12\x14\n" +
+	"\x05seats\x18\x02 \x01(\x05R\x05seats\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\rR\aversion\"\xa7\x01\n" +
+	"\fTableSummary\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12\x14\n" +
+	"\x05seats\x18\x02 \x01(\x05R\x05seats\x12%\n" +
+	"\x0ereserved_seats\x18\x03 \x01(\x05R\rreservedSeats\x12#\n" +
+	"\rarrived_seats\x18\x04 \x01(\x05R\farrivedSeats\x12\x1d\n" +
+	"\n" +
+	"free_seats\x18\x05 \x01(\x05R\tfreeSeats\"\xc1\x01\n" +
+	"\vReservation\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05table\x18\x02 \x01(\x05R\x05table\x12/\n" +
+	"\x13accompanying_guests\x18\x03 \x01(\x05R\x12accompanyingGuests\x12=\n" +
+	"\farrival_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\varrivalTime\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\rR\aversion\"\x87\x01\n" +
+	"\x05Seats\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12\x1a\n" +
+	"\breserved\x18\x02 \x01(\x05R\breserved\x12\x18\n" +
+	"\aarrived\x18\x03 \x01(\x05R\aarrived\x12\x14\n" +
+	"\x05empty\x18\x04 \x01(\x05R\x05empty\x12\x1c\n" +
+	"\tavailable\x18\x05 \x01(\x05R\tavailable\"\x13\n" +
+	"\x11ListTablesRequest\"H\n" +
+	"\x12ListTablesResponse\x122\n" +
+	"\x06tables\x18\x01 \x03(\v2\x1a.guestlist.v1.TableSummaryR\x06tables\")\n" +
+	"\x0fGetTableRequest\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\"B\n" +
+	"\x12CreateTableRequest\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12\x14\n" +
+	"\x05seats\x18\x02 \x01(\x05R\x05seats\"\xb2\x01\n" +
+	"\x12UpdateTableRequest\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12\"\n" +
+	"\n" +
+	"new_number\x18\x02 \x01(\x05H\x00R\tnewNumber\x88\x01\x01\x12\x19\n" +
+	"\x05seats\x18\x03 \x01(\x05H\x01R\x05seats\x88\x01\x01\x12,\n" +
+	"\x06shrink\x18\x04 \x01(\x0e2\x14.guestlist.v1.ShrinkR\x06shrinkB\r\n" +
+	"\v_new_numberB\b\n" +
+	"\x06_seats\"q\n" +
+	"\x13UpdateTableResponse\x12)\n" +
+	"\x05table\x18\x01 \x01(\v2\x13.guestlist.v1.TableR\x05table\x12/\n" +
+	"\x05moved\x18\x02 \x03(\v2\x19.guestlist.v1.ReservationR\x05moved\",\n" +
+	"\x12DeleteTableRequest\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\"\x19\n" +
+	"\x17ListReservationsRequest\"Y\n" +
+	"\x18ListReservationsResponse\x12=\n" +
+	"\freservations\x18\x01 \x03(\v2\x19.guestlist.v1.ReservationR\freservations\"+\n" +
+	"\x15GetReservationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"u\n" +
+	"\x18CreateReservationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05table\x18\x02 \x01(\x05R\x05table\x12/\n" +
+	"\x13accompanying_guests\x18\x03 \x01(\x05R\x12accompanyingGuests\".\n" +
+	"\x18DeleteReservationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x15\n" +
+	"\x13ListArrivalsRequest\"U\n" +
+	"\x0eCheckInRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12/\n" +
+	"\x13accompanying_guests\x18\x02 \x01(\x05R\x12accompanyingGuests\"(\n" +
+	"\x12UndoCheckInRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x11\n" +
+	"\x0fGetSeatsRequest\"\x16\n" +
+	"\x14WatchArrivalsRequest\"\xfa\x01\n" +
+	"\fArrivalEvent\x123\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1f.guestlist.v1.ArrivalEvent.TypeR\x04type\x12;\n" +
+	"\vreservation\x18\x02 \x01(\v2\x19.guestlist.v1.ReservationR\vreservation\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"H\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_ARRIVED\x10\x01\x12\x18\n" +
+	"\x14TYPE_CHECK_IN_UNDONE\x10\x02*C\n" +
+	"\x06Shrink\x12\x11\n" +
+	"\rSHRINK_REJECT\x10\x00\x12\x10\n" +
+	"\fSHRINK_FORCE\x10\x01\x12\x14\n" +
+	"\x10SHRINK_REBALANCE\x10\x022\xdf\b\n" +
+	"\tGuestList\x12O\n" +
+	"\n" +
+	"ListTables\x12\x1f.guestlist.v1.ListTablesRequest\x1a .guestlist.v1.ListTablesResponse\x12>\n" +
+	"\bGetTable\x12\x1d.guestlist.v1.GetTableRequest\x1a\x13.guestlist.v1.Table\x12D\n" +
+	"\vCreateTable\x12 .guestlist.v1.CreateTableRequest\x1a\x13.guestlist.v1.Table\x12R\n" +
+	"\vUpdateTable\x12 .guestlist.v1.UpdateTableRequest\x1a!.guestlist.v1.UpdateTableResponse\x12G\n" +
+	"\vDeleteTable\x12 .guestlist.v1.DeleteTableRequest\x1a\x16.google.protobuf.Empty\x12a\n" +
+	"\x10ListReservations\x12%.guestlist.v1.ListReservationsRequest\x1a&.guestlist.v1.ListReservationsResponse\x12P\n" +
+	"\x0eGetReservation\x12#.guestlist.v1.GetReservationRequest\x1a\x19.guestlist.v1.Reservation\x12V\n" +
+	"\x11CreateReservation\x12&.guestlist.v1.CreateReservationRequest\x1a\x19.guestlist.v1.Reservation\x12S\n" +
+	"\x11DeleteReservation\x12&.guestlist.v1.DeleteReservationRequest\x1a\x16.google.protobuf.Empty\x12Y\n" +
+	"\fListArrivals\x12!.guestlist.v1.ListArrivalsRequest\x1a&.guestlist.v1.ListReservationsResponse\x12B\n" +
+	"\aCheckIn\x12\x1c.guestlist.v1.CheckInRequest\x1a\x19.guestlist.v1.Reservation\x12J\n" +
+	"\vUndoCheckIn\x12 .guestlist.v1.UndoCheckInRequest\x1a\x19.guestlist.v1.Reservation\x12>\n" +
+	"\bGetSeats\x12\x1d.guestlist.v1.GetSeatsRequest\x1a\x13.guestlist.v1.Seats\x12Q\n" +
+	"\rWatchArrivals\x12\".guestlist.v1.WatchArrivalsRequest\x1a\x1a.guestlist.v1.ArrivalEvent0\x01B7Z5github.com/ctompkinson/guest-list/grpcapi/guestlistpbb\x06proto3"
+
+var (
+	file_guestlist_proto_rawDescOnce sync.Once
+	file_guestlist_proto_rawDescData []byte
+)
+
+func file_guestlist_proto_rawDescGZIP() []byte {
+	file_guestlist_proto_rawDescOnce.Do(func() {
+		file_guestlist_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_guestlist_proto_rawDesc), len(file_guestlist_proto_rawDesc)))
+	})
+	return file_guestlist_proto_rawDescData
+}
+
+var file_guestlist_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_guestlist_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_guestlist_proto_goTypes = []any{
+	(Shrink)(0),                      // 0: guestlist.v1.Shrink
+	(ArrivalEvent_Type)(0),           // 1: guestlist.v1.ArrivalEvent.Type
+	(*Table)(nil),                    // 2: guestlist.v1.Table
+	(*TableSummary)(nil),             // 3: guestlist.v1.TableSummary
+	(*Reservation)(nil),              // 4: guestlist.v1.Reservation
+	(*Seats)(nil),                    // 5: guestlist.v1.Seats
+	(*ListTablesRequest)(nil),        // 6: guestlist.v1.ListTablesRequest
+	(*ListTablesResponse)(nil),       // 7: guestlist.v1.ListTablesResponse
+	(*GetTableRequest)(nil),          // 8: guestlist.v1.GetTableRequest
+	(*CreateTableRequest)(nil),       // 9: guestlist.v1.CreateTableRequest
+	(*UpdateTableRequest)(nil),       // 10: guestlist.v1.UpdateTableRequest
+	(*UpdateTableResponse)(nil),      // 11: guestlist.v1.UpdateTableResponse
+	(*DeleteTableRequest)(nil),       // 12: guestlist.v1.DeleteTableRequest
+	(*ListReservationsRequest)(nil),  // 13: guestlist.v1.ListReservationsRequest
+	(*ListReservationsResponse)(nil), // 14: guestlist.v1.ListReservationsResponse
+	(*GetReservationRequest)(nil),    // 15: guestlist.v1.GetReservationRequest
+	(*CreateReservationRequest)(nil), // 16: guestlist.v1.CreateReservationRequest
+	(*DeleteReservationRequest)(nil), // 17: guestlist.v1.DeleteReservationRequest
+	(*ListArrivalsRequest)(nil),      // 18: guestlist.v1.ListArrivalsRequest
+	(*CheckInRequest)(nil),           // 19: guestlist.v1.CheckInRequest
+	(*UndoCheckInRequest)(nil),       // 20: guestlist.v1.UndoCheckInRequest
+	(*GetSeatsRequest)(nil),          // 21: guestlist.v1.GetSeatsRequest
+	(*WatchArrivalsRequest)(nil),     // 22: guestlist.v1.WatchArrivalsRequest
+	(*ArrivalEvent)(nil),             // 23: guestlist.v1.ArrivalEvent
+	(*timestamppb.Timestamp)(nil),    // 24: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),            // 25: google.protobuf.Empty
+}
+var file_guestlist_proto_depIdxs = []int32{
+	24, // 0: guestlist.v1.Reservation.arrival_time:type_name -> google.protobuf.Timestamp
+	3,  // 1: guestlist.v1.ListTablesResponse.tables:type_name -> guestlist.v1.TableSummary
+	0,  // 2: guestlist.v1.UpdateTableRequest.shrink:type_name -> guestlist.v1.Shrink
+	2,  // 3: guestlist.v1.UpdateTableResponse.table:type_name -> guestlist.v1.Table
+	4,  // 4: guestlist.v1.UpdateTableResponse.moved:type_name -> guestlist.v1.Reservation
+	4,  // 5: guestlist.v1.ListReservationsResponse.reservations:type_name -> guestlist.v1.Reservation
+	1,  // 6: guestlist.v1.ArrivalEvent.type:type_name -> guestlist.v1.ArrivalEvent.Type
+	4,  // 7: guestlist.v1.ArrivalEvent.reservation:type_name -> guestlist.v1.Reservation
+	24, // 8: guestlist.v1.ArrivalEvent.time:type_name -> google.protobuf.Timestamp
+	6,  // 9: guestlist.v1.GuestList.ListTables:input_type -> guestlist.v1.ListTablesRequest
+	8,  // 10: guestlist.v1.GuestList.GetTable:input_type -> guestlist.v1.GetTableRequest
+	9,  // 11: guestlist.v1.GuestList.CreateTable:input_type -> guestlist.v1.CreateTableRequest
+	10, // 12: guestlist.v1.GuestList.UpdateTable:input_type -> guestlist.v1.UpdateTableRequest
+	12, // 13: guestlist.v1.GuestList.DeleteTable:input_type -> guestlist.v1.DeleteTableRequest
+	13, // 14: guestlist.v1.GuestList.ListReservations:input_type -> guestlist.v1.ListReservationsRequest
+	15, // 15: guestlist.v1.GuestList.GetReservation:input_type -> guestlist.v1.GetReservationRequest
+	16, // 16: guestlist.v1.GuestList.CreateReservation:input_type -> guestlist.v1.CreateReservationRequest
+	17, // 17: guestlist.v1.GuestList.DeleteReservation:input_type -> guestlist.v1.DeleteReservationRequest
+	18, // 18: guestlist.v1.GuestList.ListArrivals:input_type -> guestlist.v1.ListArrivalsRequest
+	19, // 19: guestlist.v1.GuestList.CheckIn:input_type -> guestlist.v1.CheckInRequest
+	20, // 20: guestlist.v1.GuestList.UndoCheckIn:input_type -> guestlist.v1.UndoCheckInRequest
+	21, // 21: guestlist.v1.GuestList.GetSeats:input_type -> guestlist.v1.GetSeatsRequest
+	22, // 22: guestlist.v1.GuestList.WatchArrivals:input_type -> guestlist.v1.WatchArrivalsRequest
+	7,  // 23: guestlist.v1.GuestList.ListTables:output_type -> guestlist.v1.ListTablesResponse
+	2,  // 24: guestlist.v1.GuestList.GetTable:output_type -> guestlist.v1.Table
+	2,  // 25: guestlist.v1.GuestList.CreateTable:output_type -> guestlist.v1.Table
+	11, // 26: guestlist.v1.GuestList.UpdateTable:output_type -> guestlist.v1.UpdateTableResponse
+	25, // 27: guestlist.v1.GuestList.DeleteTable:output_type -> google.protobuf.Empty
+	14, // 28: guestlist.v1.GuestList.ListReservations:output_type -> guestlist.v1.ListReservationsResponse
+	4,  // 29: guestlist.v1.GuestList.GetReservation:output_type -> guestlist.v1.Reservation
+	4,  // 30: guestlist.v1.GuestList.CreateReservation:output_type -> guestlist.v1.Reservation
+	25, // 31: guestlist.v1.GuestList.DeleteReservation:output_type -> google.protobuf.Empty
+	14, // 32: guestlist.v1.GuestList.ListArrivals:output_type -> guestlist.v1.ListReservationsResponse
+	4,  // 33: guestlist.v1.GuestList.CheckIn:output_type -> guestlist.v1.Reservation
+	4,  // 34: guestlist.v1.GuestList.UndoCheckIn:output_type -> guestlist.v1.Reservation
+	5,  // 35: guestlist.v1.GuestList.GetSeats:output_type -> guestlist.v1.Seats
+	23, // 36: guestlist.v1.GuestList.WatchArrivals:output_type -> guestlist.v1.ArrivalEvent
+	23, // [23:37] is the sub-list for method output_type
+	9,  // [9:23] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_guestlist_proto_init() }
+func file_guestlist_proto_init() {
+	if File_guestlist_proto != nil {
+		return
+	}
+	file_guestlist_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_guestlist_proto_rawDesc), len(file_guestlist_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_guestlist_proto_goTypes,
+		DependencyIndexes: file_guestlist_proto_depIdxs,
+		EnumInfos:         file_guestlist_proto_enumTypes,
+		MessageInfos:      file_guestlist_proto_msgTypes,
+	}.Build()
+	File_guestlist_proto = out.File
+	file_guestlist_proto_goTypes = nil
+	file_guestlist_proto_depIdxs = nil
+}
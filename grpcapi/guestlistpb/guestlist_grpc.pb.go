@@ -0,0 +1,640 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: guestlist.proto
+
+package guestlistpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GuestList_ListTables_FullMethodName        = "/guestlist.v1.GuestList/ListTables"
+	GuestList_GetTable_FullMethodName          = "/guestlist.v1.GuestList/GetTable"
+	GuestList_CreateTable_FullMethodName       = "/guestlist.v1.GuestList/CreateTable"
+	GuestList_UpdateTable_FullMethodName       = "/guestlist.v1.GuestList/UpdateTable"
+	GuestList_DeleteTable_FullMethodName       = "/guestlist.v1.GuestList/DeleteTable"
+	GuestList_ListReservations_FullMethodName  = "/guestlist.v1.GuestList/ListReservations"
+	GuestList_GetReservation_FullMethodName    = "/guestlist.v1.GuestList/GetReservation"
+	GuestList_CreateReservation_FullMethodName = "/guestlist.v1.GuestList/CreateReservation"
+	GuestList_DeleteReservation_FullMethodName = "/guestlist.v1.GuestList/DeleteReservation"
+	GuestList_ListArrivals_FullMethodName      = "/guestlist.v1.GuestList/ListArrivals"
+	GuestList_CheckIn_FullMethodName           = "/guestlist.v1.GuestList/CheckIn"
+	GuestList_UndoCheckIn_FullMethodName       = "/guestlist.v1.GuestList/UndoCheckIn"
+	GuestList_GetSeats_FullMethodName          = "/guestlist.v1.GuestList/GetSeats"
+	GuestList_WatchArrivals_FullMethodName     = "/guestlist.v1.GuestList/WatchArrivals"
+)
+
+// GuestListClient is the client API for GuestList service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GuestList manages the tables, reservations and arrivals of the party with the same rules as the HTTP API. Every call
+// needs an API key or token in the authorization metadata as "Bearer <key>", or in x-api-key
+type GuestListClient interface {
+	ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesResponse, error)
+	GetTable(ctx context.Context, in *GetTableRequest, opts ...grpc.CallOption) (*Table, error)
+	CreateTable(ctx context.Context, in *CreateTableRequest, opts ...grpc.CallOption) (*Table, error)
+	// UpdateTable changes a table's number or seats, see Shrink for tables with more reserved seats than it will have
+	UpdateTable(ctx context.Context, in *UpdateTableRequest, opts ...grpc.CallOption) (*UpdateTableResponse, error)
+	// DeleteTable deletes a table, tables with reservations can't be deleted
+	DeleteTable(ctx context.Context, in *DeleteTableRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error)
+	GetReservation(ctx context.Context, in *GetReservationRequest, opts ...grpc.CallOption) (*Reservation, error)
+	CreateReservation(ctx context.Context, in *CreateReservationRequest, opts ...grpc.CallOption) (*Reservation, error)
+	DeleteReservation(ctx context.Context, in *DeleteReservationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListArrivals lists the reservations of every guest that has arrived
+	ListArrivals(ctx context.Context, in *ListArrivalsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error)
+	// CheckIn marks a guest as arrived, they may bring a different amount of guests as long as their table has room
+	CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*Reservation, error)
+	// UndoCheckIn clears a guest's arrival, keeping their reservation
+	UndoCheckIn(ctx context.Context, in *UndoCheckInRequest, opts ...grpc.CallOption) (*Reservation, error)
+	GetSeats(ctx context.Context, in *GetSeatsRequest, opts ...grpc.CallOption) (*Seats, error)
+	// WatchArrivals sends an event every time a guest is checked in or their check in is undone, until the client
+	// cancels. Only changes made after the call starts are sent
+	WatchArrivals(ctx context.Context, in *WatchArrivalsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArrivalEvent], error)
+}
+
+type guestListClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGuestListClient(cc grpc.ClientConnInterface) GuestListClient {
+	return &guestListClient{cc}
+}
+
+func (c *guestListClient) ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTablesResponse)
+	err := c.cc.Invoke(ctx, GuestList_ListTables_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) GetTable(ctx context.Context, in *GetTableRequest, opts ...grpc.CallOption) (*Table, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Table)
+	err := c.cc.Invoke(ctx, GuestList_GetTable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) CreateTable(ctx context.Context, in *CreateTableRequest, opts ...grpc.CallOption) (*Table, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Table)
+	err := c.cc.Invoke(ctx, GuestList_CreateTable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) UpdateTable(ctx context.Context, in *UpdateTableRequest, opts ...grpc.CallOption) (*UpdateTableResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateTableResponse)
+	err := c.cc.Invoke(ctx, GuestList_UpdateTable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) DeleteTable(ctx context.Context, in *DeleteTableRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GuestList_DeleteTable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReservationsResponse)
+	err := c.cc.Invoke(ctx, GuestList_ListReservations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) GetReservation(ctx context.Context, in *GetReservationRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, GuestList_GetReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) CreateReservation(ctx context.Context, in *CreateReservationRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, GuestList_CreateReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) DeleteReservation(ctx context.Context, in *DeleteReservationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GuestList_DeleteReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) ListArrivals(ctx context.Context, in *ListArrivalsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReservationsResponse)
+	err := c.cc.Invoke(ctx, GuestList_ListArrivals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, GuestList_CheckIn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) UndoCheckIn(ctx context.Context, in *UndoCheckInRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, GuestList_UndoCheckIn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) GetSeats(ctx context.Context, in *GetSeatsRequest, opts ...grpc.CallOption) (*Seats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Seats)
+	err := c.cc.Invoke(ctx, GuestList_GetSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *guestListClient) WatchArrivals(ctx context.Context, in *WatchArrivalsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArrivalEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GuestList_ServiceDesc.Streams[0], GuestList_WatchArrivals_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchArrivalsRequest, ArrivalEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GuestList_WatchArrivalsClient = grpc.ServerStreamingClient[ArrivalEvent]
+
+// GuestListServer is the server API for GuestList service.
+// All implementations must embed UnimplementedGuestListServer
+// for forward compatibility.
+//
+// GuestList manages the tables, reservations and arrivals of the party with the same rules as the HTTP API. Every call
+// needs an API key or token in the authorization metadata as "Bearer <key>", or in x-api-key
+type GuestListServer interface {
+	ListTables(context.Context, *ListTablesRequest) (*ListTablesResponse, error)
+	GetTable(context.Context, *GetTableRequest) (*Table, error)
+	CreateTable(context.Context, *CreateTableRequest) (*Table, error)
+	// UpdateTable changes a table's number or seats, see Shrink for tables with more reserved seats than it will have
+	UpdateTable(context.Context, *UpdateTableRequest) (*UpdateTableResponse, error)
+	// DeleteTable deletes a table, tables with reservations can't be deleted
+	DeleteTable(context.Context, *DeleteTableRequest) (*emptypb.Empty, error)
+	ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error)
+	GetReservation(context.Context, *GetReservationRequest) (*Reservation, error)
+	CreateReservation(context.Context, *CreateReservationRequest) (*Reservation, error)
+	DeleteReservation(context.Context, *DeleteReservationRequest) (*emptypb.Empty, error)
+	// ListArrivals lists the reservations of every guest that has arrived
+	ListArrivals(context.Context, *ListArrivalsRequest) (*ListReservationsResponse, error)
+	// CheckIn marks a guest as arrived, they may bring a different amount of guests as long as their table has room
+	CheckIn(context.Context, *CheckInRequest) (*Reservation, error)
+	// UndoCheckIn clears a guest's arrival, keeping their reservation
+	UndoCheckIn(context.Context, *UndoCheckInRequest) (*Reservation, error)
+	GetSeats(context.Context, *GetSeatsRequest) (*Seats, error)
+	// WatchArrivals sends an event every time a guest is checked in or their check in is undone, until the client
+	// cancels. Only changes made after the call starts are sent
+	WatchArrivals(*WatchArrivalsRequest, grpc.ServerStreamingServer[ArrivalEvent]) error
+	mustEmbedUnimplementedGuestListServer()
+}
+
+// UnimplementedGuestListServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGuestListServer struct{}
+
+func (UnimplementedGuestListServer) ListTables(context.Context, *ListTablesRequest) (*ListTablesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListTables not implemented")
+}
+func (UnimplementedGuestListServer) GetTable(context.Context, *GetTableRequest) (*Table, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTable not implemented")
+}
+func (UnimplementedGuestListServer) CreateTable(context.Context, *CreateTableRequest) (*Table, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateTable not implemented")
+}
+func (UnimplementedGuestListServer) UpdateTable(context.Context, *UpdateTableRequest) (*UpdateTableResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateTable not implemented")
+}
+func (UnimplementedGuestListServer) DeleteTable(context.Context, *DeleteTableRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteTable not implemented")
+}
+func (UnimplementedGuestListServer) ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListReservations not implemented")
+}
+func (UnimplementedGuestListServer) GetReservation(context.Context, *GetReservationRequest) (*Reservation, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetReservation not implemented")
+}
+func (UnimplementedGuestListServer) CreateReservation(context.Context, *CreateReservationRequest) (*Reservation, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateReservation not implemented")
+}
+func (UnimplementedGuestListServer) DeleteReservation(context.Context, *DeleteReservationRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteReservation not implemented")
+}
+func (UnimplementedGuestListServer) ListArrivals(context.Context, *ListArrivalsRequest) (*ListReservationsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListArrivals not implemented")
+}
+func (UnimplementedGuestListServer) CheckIn(context.Context, *CheckInRequest) (*Reservation, error) {
+	return nil, status.Error(codes.Unimplemented, "method CheckIn not implemented")
+}
+func (UnimplementedGuestListServer) UndoCheckIn(context.Context, *UndoCheckInRequest) (*Reservation, error) {
+	return nil, status.Error(codes.Unimplemented, "method UndoCheckIn not implemented")
+}
+func (UnimplementedGuestListServer) GetSeats(context.Context, *GetSeatsRequest) (*Seats, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSeats not implemented")
+}
+func (UnimplementedGuestListServer) WatchArrivals(*WatchArrivalsRequest, grpc.ServerStreamingServer[ArrivalEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchArrivals not implemented")
+}
+func (UnimplementedGuestListServer) mustEmbedUnimplementedGuestListServer() {}
+func (UnimplementedGuestListServer) testEmbeddedByValue()                   {}
+
+// UnsafeGuestListServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GuestListServer will
+// result in compilation errors.
+type UnsafeGuestListServer interface {
+	mustEmbedUnimplementedGuestListServer()
+}
+
+func RegisterGuestListServer(s grpc.ServiceRegistrar, srv GuestListServer) {
+	// If the following call panics, it indicates UnimplementedGuestListServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GuestList_ServiceDesc, srv)
+}
+
+func _GuestList_ListTables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTablesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).ListTables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_ListTables_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).ListTables(ctx, req.(*ListTablesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_GetTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).GetTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_GetTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).GetTable(ctx, req.(*GetTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_CreateTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).CreateTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_CreateTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).CreateTable(ctx, req.(*CreateTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_UpdateTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).UpdateTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_UpdateTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).UpdateTable(ctx, req.(*UpdateTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_DeleteTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).DeleteTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_DeleteTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).DeleteTable(ctx, req.(*DeleteTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_ListReservations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReservationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).ListReservations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_ListReservations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).ListReservations(ctx, req.(*ListReservationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_GetReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).GetReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_GetReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).GetReservation(ctx, req.(*GetReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_CreateReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).CreateReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_CreateReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).CreateReservation(ctx, req.(*CreateReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_DeleteReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).DeleteReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_DeleteReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).DeleteReservation(ctx, req.(*DeleteReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_ListArrivals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArrivalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).ListArrivals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_ListArrivals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).ListArrivals(ctx, req.(*ListArrivalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_CheckIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).CheckIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_CheckIn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).CheckIn(ctx, req.(*CheckInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_UndoCheckIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndoCheckInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).UndoCheckIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_UndoCheckIn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).UndoCheckIn(ctx, req.(*UndoCheckInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_GetSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GuestListServer).GetSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GuestList_GetSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GuestListServer).GetSeats(ctx, req.(*GetSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GuestList_WatchArrivals_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchArrivalsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GuestListServer).WatchArrivals(m, &grpc.GenericServerStream[WatchArrivalsRequest, ArrivalEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GuestList_WatchArrivalsServer = grpc.ServerStreamingServer[ArrivalEvent]
+
+// GuestList_ServiceDesc is the grpc.ServiceDesc for GuestList service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GuestList_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "guestlist.v1.GuestList",
+	HandlerType: (*GuestListServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTables",
+			Handler:    _GuestList_ListTables_Handler,
+		},
+		{
+			MethodName: "GetTable",
+			Handler:    _GuestList_GetTable_Handler,
+		},
+		{
+			MethodName: "CreateTable",
+			Handler:    _GuestList_CreateTable_Handler,
+		},
+		{
+			MethodName: "UpdateTable",
+			Handler:    _GuestList_UpdateTable_Handler,
+		},
+		{
+			MethodName: "DeleteTable",
+			Handler:    _GuestList_DeleteTable_Handler,
+		},
+		{
+			MethodName: "ListReservations",
+			Handler:    _GuestList_ListReservations_Handler,
+		},
+		{
+			MethodName: "GetReservation",
+			Handler:    _GuestList_GetReservation_Handler,
+		},
+		{
+			MethodName: "CreateReservation",
+			Handler:    _GuestList_CreateReservation_Handler,
+		},
+		{
+			MethodName: "DeleteReservation",
+			Handler:    _GuestList_DeleteReservation_Handler,
+		},
+		{
+			MethodName: "ListArrivals",
+			Handler:    _GuestList_ListArrivals_Handler,
+		},
+		{
+			MethodName: "CheckIn",
+			Handler:    _GuestList_CheckIn_Handler,
+		},
+		{
+			MethodName: "UndoCheckIn",
+			Handler:    _GuestList_UndoCheckIn_Handler,
+		},
+		{
+			MethodName: "GetSeats",
+			Handler:    _GuestList_GetSeats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchArrivals",
+			Handler:       _GuestList_WatchArrivals_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "guestlist.proto",
+}
@@ -0,0 +1,162 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/grpcapi/guestlistpb"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/requestid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"time"
+)
+
+// permissions is what each call needs, the same as its HTTP route. Calls missing from here are refused
+var permissions = map[string]auth.Permission{
+	guestlistpb.GuestList_ListTables_FullMethodName:        auth.Read,
+	guestlistpb.GuestList_GetTable_FullMethodName:          auth.Read,
+	guestlistpb.GuestList_CreateTable_FullMethodName:       auth.Plan,
+	guestlistpb.GuestList_UpdateTable_FullMethodName:       auth.Plan,
+	guestlistpb.GuestList_DeleteTable_FullMethodName:       auth.Plan,
+	guestlistpb.GuestList_ListReservations_FullMethodName:  auth.Read,
+	guestlistpb.GuestList_GetReservation_FullMethodName:    auth.Read,
+	guestlistpb.GuestList_CreateReservation_FullMethodName: auth.Plan,
+	guestlistpb.GuestList_DeleteReservation_FullMethodName: auth.Plan,
+	guestlistpb.GuestList_ListArrivals_FullMethodName:      auth.Read,
+	guestlistpb.GuestList_CheckIn_FullMethodName:           auth.CheckIn,
+	guestlistpb.GuestList_UndoCheckIn_FullMethodName:       auth.CheckIn,
+	guestlistpb.GuestList_GetSeats_FullMethodName:          auth.Read,
+	guestlistpb.GuestList_WatchArrivals_FullMethodName:     auth.Read,
+}
+
+func unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = callContext(ctx)
+
+	ctx, err := authorize(ctx, info.FullMethod)
+	var res interface{}
+	if err == nil {
+		res, err = handler(ctx, req)
+	}
+	logCall(ctx, info.FullMethod, start, err)
+	return res, err
+}
+
+func streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := callContext(ss.Context())
+
+	ctx, err := authorize(ctx, info.FullMethod)
+	if err == nil {
+		err = handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// contextStream replaces the context of a stream with one carrying who made the call
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// callContext gives a call its request ID, from the x-request-id metadata when the client sent a valid one, and starts
+// collecting fields for its log line
+func callContext(ctx context.Context) context.Context {
+	id := metadataValue(ctx, strings.ToLower(requestid.Header))
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+	return logging.WithFields(requestid.WithID(ctx, id))
+}
+
+// authorize works out who made a call from the API key or JWT in its authorization or x-api-key metadata, the same
+// as Authenticate and Require do for HTTP requests, and stores them in the call's context
+func authorize(ctx context.Context, method string) (context.Context, error) {
+	if database.FromContext(ctx) == nil {
+		return ctx, status.Error(codes.Unavailable, "the server is still connecting to the database")
+	}
+	permission, ok := permissions[method]
+	if !ok {
+		return ctx, status.Errorf(codes.PermissionDenied, "%s has no permission set", method)
+	}
+	if auth.Disabled {
+		return ctx, nil
+	}
+
+	credentials := callCredentials(ctx)
+	if credentials == "" {
+		return ctx, status.Error(codes.Unauthenticated, "authentication is required")
+	}
+
+	var principal auth.Principal
+	var err error
+	// API keys all start with the same prefix, anything else is treated as a token from the single sign on provider
+	if auth.JWT != nil && !strings.HasPrefix(credentials, auth.KeyPrefix) {
+		principal, err = auth.JWT.Validate(credentials)
+		if err != nil {
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+	} else {
+		principal, err = auth.Authenticate(database.FromContext(ctx), credentials)
+		if errors.Is(err, auth.ErrInvalidKey) {
+			return ctx, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		if err != nil {
+			logging.AddField(ctx, "cause", err.Error())
+			return ctx, status.Error(codes.Internal, "failed to check API key")
+		}
+	}
+
+	logging.AddField(ctx, "actor", principal.Name)
+	logging.AddField(ctx, "auth_method", principal.Method)
+	if !principal.Role.Allows(permission) {
+		return ctx, status.Errorf(codes.PermissionDenied, "the %s role does not have the %s permission", principal.Role, permission)
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// callCredentials gets the API key or token from a call's metadata, if there is one
+func callCredentials(ctx context.Context) string {
+	if header := metadataValue(ctx, "authorization"); header != "" {
+		if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+			return strings.TrimSpace(header[7:])
+		}
+	}
+	return metadataValue(ctx, "x-api-key")
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// logCall logs every call once it has finished, with its status code and how long it took. Server errors are logged as
+// errors along with their cause
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"grpc_method": method,
+		"grpc_code":   code.String(),
+		"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+	})
+	switch code {
+	case codes.Internal, codes.Unknown:
+		log.WithError(err).Error("call failed")
+	default:
+		log.Info("call handled")
+	}
+}
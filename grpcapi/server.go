@@ -0,0 +1,307 @@
+// Package grpcapi serves the guest list over gRPC for internal integrations, as described by guestlistpb/guestlist.proto.
+// It uses the same rules as the HTTP API through the service package, only converting requests and responses.
+//
+// The generated code in guestlistpb is updated with go generate, which needs protoc along with protoc-gen-go and
+// protoc-gen-go-grpc
+package grpcapi
+
+//go:generate protoc --go_out=guestlistpb --go_opt=paths=source_relative --go-grpc_out=guestlistpb --go-grpc_opt=paths=source_relative -I guestlistpb guestlist.proto
+
+import (
+	"context"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/grpcapi/guestlistpb"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// watchBuffer is how many arrival events a WatchArrivals stream can fall behind by before it is ended
+const watchBuffer = 100
+
+type server struct {
+	guestlistpb.UnimplementedGuestListServer
+}
+
+// NewServer creates a gRPC server with the guest list service registered. The interceptors of any options given run
+// before the server's own, which authenticate every call and log it once it is done
+func NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(unaryInterceptor), grpc.ChainStreamInterceptor(streamInterceptor))
+	s := grpc.NewServer(opts...)
+	guestlistpb.RegisterGuestListServer(s, &server{})
+	return s
+}
+
+// callDB gets the database with the call's context, so changes made with it know who made them
+func callDB(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx).WithContext(ctx)
+}
+
+func (s *server) ListTables(ctx context.Context, req *guestlistpb.ListTablesRequest) (*guestlistpb.ListTablesResponse, error) {
+	summaries, err := service.ListTables(callDB(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	res := &guestlistpb.ListTablesResponse{}
+	for _, s := range summaries {
+		res.Tables = append(res.Tables, &guestlistpb.TableSummary{
+			Number:        int32(s.Number),
+			Seats:         int32(s.Seats),
+			ReservedSeats: int32(s.ReservedSeats),
+			ArrivedSeats:  int32(s.ArrivedSeats),
+			FreeSeats:     int32(s.FreeSeats),
+		})
+	}
+	return res, nil
+}
+
+func (s *server) GetTable(ctx context.Context, req *guestlistpb.GetTableRequest) (*guestlistpb.Table, error) {
+	table, err := service.GetTable(callDB(ctx), int(req.GetNumber()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newTable(table), nil
+}
+
+func (s *server) CreateTable(ctx context.Context, req *guestlistpb.CreateTableRequest) (*guestlistpb.Table, error) {
+	table, err := service.CreateTable(callDB(ctx), service.NewTable{Number: int(req.GetNumber()), Seats: int(req.GetSeats())})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newTable(table), nil
+}
+
+func (s *server) UpdateTable(ctx context.Context, req *guestlistpb.UpdateTableRequest) (*guestlistpb.UpdateTableResponse, error) {
+	db := callDB(ctx)
+	table, err := service.GetTable(db, int(req.GetNumber()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	update := service.TableUpdate{}
+	if req.NewNumber != nil {
+		number := int(req.GetNewNumber())
+		update.Number = &number
+	}
+	if req.Seats != nil {
+		seats := int(req.GetSeats())
+		update.Seats = &seats
+	}
+	switch req.GetShrink() {
+	case guestlistpb.Shrink_SHRINK_REJECT:
+		update.Shrink = service.ShrinkReject
+	case guestlistpb.Shrink_SHRINK_FORCE:
+		update.Shrink = service.ShrinkForce
+	case guestlistpb.Shrink_SHRINK_REBALANCE:
+		update.Shrink = service.ShrinkRebalance
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown shrink option %d", req.GetShrink())
+	}
+
+	table, moved, err := service.UpdateTable(db, table, update)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	res := &guestlistpb.UpdateTableResponse{Table: newTable(table)}
+	for _, r := range moved {
+		res.Moved = append(res.Moved, newReservation(r))
+	}
+	return res, nil
+}
+
+func (s *server) DeleteTable(ctx context.Context, req *guestlistpb.DeleteTableRequest) (*emptypb.Empty, error) {
+	db := callDB(ctx)
+	table, err := service.GetTable(db, int(req.GetNumber()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if err := service.DeleteTable(db, table); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListReservations(ctx context.Context, req *guestlistpb.ListReservationsRequest) (*guestlistpb.ListReservationsResponse, error) {
+	reservations, err := service.ListReservations(callDB(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newReservationList(reservations), nil
+}
+
+func (s *server) GetReservation(ctx context.Context, req *guestlistpb.GetReservationRequest) (*guestlistpb.Reservation, error) {
+	reservation, err := service.GetReservation(callDB(ctx), req.GetName())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newReservation(reservation), nil
+}
+
+func (s *server) CreateReservation(ctx context.Context, req *guestlistpb.CreateReservationRequest) (*guestlistpb.Reservation, error) {
+	reservation, err := service.CreateReservation(callDB(ctx), service.NewReservation{
+		Guest:              req.GetName(),
+		TableNumber:        int(req.GetTable()),
+		AccompanyingGuests: int(req.GetAccompanyingGuests()),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newReservation(reservation), nil
+}
+
+func (s *server) DeleteReservation(ctx context.Context, req *guestlistpb.DeleteReservationRequest) (*emptypb.Empty, error) {
+	db := callDB(ctx)
+	reservation, err := service.GetReservation(db, req.GetName())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if err := service.DeleteReservation(db, reservation); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListArrivals(ctx context.Context, req *guestlistpb.ListArrivalsRequest) (*guestlistpb.ListReservationsResponse, error) {
+	reservations, err := service.ListArrivals(callDB(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newReservationList(reservations), nil
+}
+
+func (s *server) CheckIn(ctx context.Context, req *guestlistpb.CheckInRequest) (*guestlistpb.Reservation, error) {
+	db := callDB(ctx)
+	reservation, err := service.GetReservation(db, req.GetName())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	reservation, err = service.CheckIn(db, reservation, int(req.GetAccompanyingGuests()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newReservation(reservation), nil
+}
+
+func (s *server) UndoCheckIn(ctx context.Context, req *guestlistpb.UndoCheckInRequest) (*guestlistpb.Reservation, error) {
+	db := callDB(ctx)
+	reservation, err := service.GetReservation(db, req.GetName())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	reservation, err = service.UndoCheckIn(db, reservation)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return newReservation(reservation), nil
+}
+
+func (s *server) GetSeats(ctx context.Context, req *guestlistpb.GetSeatsRequest) (*guestlistpb.Seats, error) {
+	count, err := service.CountSeats(callDB(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &guestlistpb.Seats{
+		Total:     int32(count.Total),
+		Reserved:  int32(count.Reserved),
+		Arrived:   int32(count.Arrived),
+		Empty:     int32(count.Total - count.Arrived),
+		Available: int32(count.Total - count.Reserved),
+	}, nil
+}
+
+func (s *server) WatchArrivals(req *guestlistpb.WatchArrivalsRequest, stream grpc.ServerStreamingServer[guestlistpb.ArrivalEvent]) error {
+	events, stop := service.SubscribeArrivals(watchBuffer)
+	defer stop()
+	// Sending the headers tells the client it is subscribed, nothing that happens from then on will be missed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "fell too far behind the arrivals, watch again and catch up with ListArrivals")
+			}
+			if err := stream.Send(newArrivalEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toStatus turns an error from the service package into a gRPC status, the code is decided by its apierror kind. Only
+// the error's message is sent, the whole error is added to the call's log line
+func toStatus(ctx context.Context, err error) error {
+	logging.AddField(ctx, "cause", err.Error())
+	return status.Error(code(apierror.KindOf(err)), apierror.Message(err))
+}
+
+// code is the gRPC status code for each kind of error
+func code(kind apierror.Kind) codes.Code {
+	switch kind {
+	case apierror.KindBadRequest, apierror.KindValidation:
+		return codes.InvalidArgument
+	case apierror.KindUnauthorized:
+		return codes.Unauthenticated
+	case apierror.KindForbidden:
+		return codes.PermissionDenied
+	case apierror.KindNotFound:
+		return codes.NotFound
+	case apierror.KindConflict:
+		return codes.AlreadyExists
+	case apierror.KindCapacityExceeded, apierror.KindPreconditionFailed:
+		return codes.FailedPrecondition
+	case apierror.KindUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+func newTable(t model.Table) *guestlistpb.Table {
+	return &guestlistpb.Table{Number: int32(t.Number), Seats: int32(t.Seats), Version: uint32(t.Version)}
+}
+
+func newReservation(r model.Reservation) *guestlistpb.Reservation {
+	res := &guestlistpb.Reservation{
+		Name:               r.Guest,
+		Table:              int32(r.Table.Number),
+		AccompanyingGuests: int32(r.AccompanyingGuests),
+		Version:            uint32(r.Version),
+	}
+	if r.ArrivalTime != nil {
+		res.ArrivalTime = timestamppb.New(*r.ArrivalTime)
+	}
+	return res
+}
+
+func newReservationList(reservations []model.Reservation) *guestlistpb.ListReservationsResponse {
+	res := &guestlistpb.ListReservationsResponse{}
+	for _, r := range reservations {
+		res.Reservations = append(res.Reservations, newReservation(r))
+	}
+	return res
+}
+
+func newArrivalEvent(e service.ArrivalEvent) *guestlistpb.ArrivalEvent {
+	event := &guestlistpb.ArrivalEvent{Reservation: newReservation(e.Reservation), Time: timestamppb.New(e.Time)}
+	switch e.Type {
+	case service.Arrived:
+		event.Type = guestlistpb.ArrivalEvent_TYPE_ARRIVED
+	case service.CheckInUndone:
+		event.Type = guestlistpb.ArrivalEvent_TYPE_CHECK_IN_UNDONE
+	}
+	return event
+}
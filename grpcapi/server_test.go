@@ -0,0 +1,242 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/grpcapi/guestlistpb"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+	"net"
+	"testing"
+	"time"
+)
+
+// newTestClient starts a server on its own test database, returning a client for it and the database. Calls are made
+// with an admin API key unless the context says otherwise
+func newTestClient(t *testing.T) (guestlistpb.GuestListClient, *gorm.DB) {
+	t.Helper()
+	db := databasetest.New(t)
+	withDB := func(ctx context.Context) context.Context { return database.WithDB(ctx, db) }
+
+	s := NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(withDB(ctx), req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, &contextStream{ServerStream: ss, ctx: withDB(ss.Context())})
+		}),
+	)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	key, err := auth.NewKey()
+	require.NoError(t, err)
+	_, err = auth.CreateKey(db, "test", auth.RoleAdmin, key)
+	require.NoError(t, err)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(withKey(ctx, key), method, req, reply, cc, opts...)
+		}),
+		grpc.WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(withKey(ctx, key), desc, cc, method, opts...)
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return guestlistpb.NewGuestListClient(conn), db
+}
+
+type keyOverride struct{}
+
+// withKey authenticates a call with key, unless the test already chose its own credentials with asKey
+func withKey(ctx context.Context, key string) context.Context {
+	if override, ok := ctx.Value(keyOverride{}).(string); ok {
+		key = override
+	}
+	if key == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+key)
+}
+
+func asKey(key string) context.Context {
+	return context.WithValue(context.Background(), keyOverride{}, key)
+}
+
+func TestTablesAndReservations(t *testing.T) {
+	t.Parallel()
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	table, err := client.CreateTable(ctx, &guestlistpb.CreateTableRequest{Number: 1, Seats: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), table.Seats)
+	_, err = client.CreateTable(ctx, &guestlistpb.CreateTableRequest{Number: 2, Seats: 2})
+	require.NoError(t, err)
+
+	_, err = client.CreateTable(ctx, &guestlistpb.CreateTableRequest{Number: 1, Seats: 4})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.CreateReservation(ctx, &guestlistpb.CreateReservationRequest{Name: "bob", Table: 1, AccompanyingGuests: 2})
+	require.NoError(t, err)
+	_, err = client.CreateReservation(ctx, &guestlistpb.CreateReservationRequest{Name: "alice", Table: 1, AccompanyingGuests: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = client.CreateReservation(ctx, &guestlistpb.CreateReservationRequest{Name: "alice", Table: 1})
+	require.NoError(t, err)
+
+	// Shrinking table 1 moves alice, the most recent reservation, to table 2
+	seats := int32(3)
+	_, err = client.UpdateTable(ctx, &guestlistpb.UpdateTableRequest{Number: 1, Seats: &seats})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	updated, err := client.UpdateTable(ctx, &guestlistpb.UpdateTableRequest{Number: 1, Seats: &seats, Shrink: guestlistpb.Shrink_SHRINK_REBALANCE})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), updated.Table.Seats)
+	require.Len(t, updated.Moved, 1)
+	assert.Equal(t, "alice", updated.Moved[0].Name)
+	assert.Equal(t, int32(2), updated.Moved[0].Table)
+
+	tables, err := client.ListTables(ctx, &guestlistpb.ListTablesRequest{})
+	require.NoError(t, err)
+	require.Len(t, tables.Tables, 2)
+	assert.Equal(t, int32(0), tables.Tables[0].FreeSeats)
+	assert.Equal(t, int32(1), tables.Tables[1].FreeSeats)
+
+	reservations, err := client.ListReservations(ctx, &guestlistpb.ListReservationsRequest{})
+	require.NoError(t, err)
+	require.Len(t, reservations.Reservations, 2)
+	assert.Equal(t, "alice", reservations.Reservations[0].Name)
+
+	_, err = client.DeleteTable(ctx, &guestlistpb.DeleteTableRequest{Number: 2})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.DeleteReservation(ctx, &guestlistpb.DeleteReservationRequest{Name: "alice"})
+	require.NoError(t, err)
+	_, err = client.DeleteTable(ctx, &guestlistpb.DeleteTableRequest{Number: 2})
+	require.NoError(t, err)
+	_, err = client.GetTable(ctx, &guestlistpb.GetTableRequest{Number: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestArrivals(t *testing.T) {
+	t.Parallel()
+	client, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := client.CreateTable(ctx, &guestlistpb.CreateTableRequest{Number: 1, Seats: 4})
+	require.NoError(t, err)
+	_, err = client.CreateReservation(ctx, &guestlistpb.CreateReservationRequest{Name: "bob", Table: 1, AccompanyingGuests: 1})
+	require.NoError(t, err)
+
+	stream, err := client.WatchArrivals(ctx, &guestlistpb.WatchArrivalsRequest{})
+	require.NoError(t, err)
+	// The headers are sent once the server has subscribed, so nothing after this can be missed
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	arrived, err := client.CheckIn(ctx, &guestlistpb.CheckInRequest{Name: "bob", AccompanyingGuests: 2})
+	require.NoError(t, err)
+	assert.NotNil(t, arrived.ArrivalTime)
+	_, err = client.CheckIn(ctx, &guestlistpb.CheckInRequest{Name: "carol"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	seats, err := client.GetSeats(ctx, &guestlistpb.GetSeatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{4, 3, 3, 1, 1}, []int32{seats.Total, seats.Reserved, seats.Arrived, seats.Empty, seats.Available})
+
+	list, err := client.ListArrivals(ctx, &guestlistpb.ListArrivalsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Reservations, 1)
+
+	_, err = client.UndoCheckIn(ctx, &guestlistpb.UndoCheckInRequest{Name: "bob"})
+	require.NoError(t, err)
+
+	var events []*guestlistpb.ArrivalEvent
+	for len(events) < 2 {
+		e, err := stream.Recv()
+		require.NoError(t, err)
+		events = append(events, e)
+	}
+	assert.Equal(t, guestlistpb.ArrivalEvent_TYPE_ARRIVED, events[0].Type)
+	assert.Equal(t, int32(2), events[0].Reservation.AccompanyingGuests)
+	assert.Equal(t, guestlistpb.ArrivalEvent_TYPE_CHECK_IN_UNDONE, events[1].Type)
+	assert.Nil(t, events[1].Reservation.ArrivalTime)
+}
+
+func TestAuthorization(t *testing.T) {
+	t.Parallel()
+	client, db := newTestClient(t)
+
+	doorKey, err := auth.NewKey()
+	require.NoError(t, err)
+	_, err = auth.CreateKey(db, "door", auth.RoleDoorStaff, doorKey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		key          string
+		call         func(ctx context.Context) error
+		expectedCode codes.Code
+	}{
+		{"noKey", "", func(ctx context.Context) error {
+			_, err := client.GetSeats(ctx, &guestlistpb.GetSeatsRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"invalidKey", auth.KeyPrefix + "nope", func(ctx context.Context) error {
+			_, err := client.GetSeats(ctx, &guestlistpb.GetSeatsRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"allowed", doorKey, func(ctx context.Context) error {
+			_, err := client.GetSeats(ctx, &guestlistpb.GetSeatsRequest{})
+			return err
+		}, codes.OK},
+		{"forbidden", doorKey, func(ctx context.Context) error {
+			_, err := client.CreateTable(ctx, &guestlistpb.CreateTableRequest{Number: 1, Seats: 4})
+			return err
+		}, codes.PermissionDenied},
+		{"streamWithoutKey", "", func(ctx context.Context) error {
+			stream, err := client.WatchArrivals(ctx, &guestlistpb.WatchArrivalsRequest{})
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		}, codes.Unauthenticated},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.call(asKey(c.key))
+			assert.Equal(t, c.expectedCode, status.Code(err), "%v", err)
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	t.Parallel()
+	ctx := logging.WithFields(context.Background())
+
+	err := toStatus(ctx, apierror.Internal(errors.New("dial tcp 10.0.0.2:3306: connection refused"), "failed to load tables"))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "failed to load tables", status.Convert(err).Message())
+	assert.Contains(t, logging.FromContext(ctx).Data["cause"], "connection refused")
+
+	err = toStatus(ctx, apierror.NotFound("table 1 does not exist"))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "table 1 does not exist", status.Convert(err).Message())
+}
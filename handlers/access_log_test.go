@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/requestid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logging.Log.SetOutput(&buf)
+	require.NoError(t, logging.Configure("json", "info"))
+	defer func() {
+		logging.Log.SetOutput(os.Stderr)
+		_ = logging.Configure("text", "info")
+	}()
+
+	router := mux.NewRouter()
+	router.Use(RequestID, AccessLog)
+	router.HandleFunc("/tables/{tableNumber}", func(w http.ResponseWriter, r *http.Request) {
+		logging.AddField(r.Context(), "actor", "door")
+		ErrorResponse(w, r, apierror.NotFound("table 3 does not exist"))
+	})
+
+	req, err := http.NewRequest("GET", "/tables/3", nil)
+	require.NoError(t, err)
+	req.Header.Set(requestid.Header, "req-1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var errorLine, accessLine map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &errorLine))
+	require.NoError(t, json.Unmarshal(lines[1], &accessLine))
+
+	assert.Equal(t, "warning", errorLine["level"])
+	assert.Equal(t, "table 3 does not exist", errorLine["msg"])
+	assert.Equal(t, "not_found", errorLine["code"])
+	assert.Equal(t, "req-1", errorLine["request_id"])
+
+	assert.Equal(t, "request handled", accessLine["msg"])
+	assert.Equal(t, "GET", accessLine["method"])
+	assert.Equal(t, "/tables/{tableNumber}", accessLine["route"])
+	assert.Equal(t, float64(http.StatusNotFound), accessLine["status"])
+	assert.Equal(t, float64(rr.Body.Len()), accessLine["bytes"])
+	assert.Equal(t, "door", accessLine["actor"])
+	assert.Equal(t, "req-1", accessLine["request_id"])
+}
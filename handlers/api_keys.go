@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+type createAPIKeyRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// HandleCreateAPIKey creates a new API key with a role, the key is only returned this once and is stored hashed
+func HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+	// POST /api_keys
+	// { "name": string, "role": "admin|planner|door_staff|read_only" }
+
+	var reqBody createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+	if reqBody.Name == "" {
+		ErrorResponse(w, r, apierror.Validation("name is required"))
+		return
+	}
+	if !auth.IsRole(reqBody.Role) {
+		ErrorResponse(w, r, apierror.Validation("unknown role %q, must be admin, planner, door_staff or read_only", reqBody.Role))
+		return
+	}
+
+	key, err := auth.NewKey()
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to generate key"))
+		return
+	}
+	apiKey, err := auth.CreateKey(db, reqBody.Name, auth.Role(reqBody.Role), key)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to create API key"))
+		return
+	}
+
+	// Only time the key is ever given back, so it isn't saved for idempotent retries or cached either
+	w.Header().Set("Cache-Control", "no-store")
+	formatted := apiKey.FormatAsAPIKey()
+	formatted.Key = key
+	out, err := json.Marshal(formatted)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal response"))
+		return
+	}
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// HandleListAPIKeys lists every API key without the keys themselves
+func HandleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	var apiKeys []model.APIKey
+	if err := db.Order("id").Find(&apiKeys).Error; err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to load API keys"))
+		return
+	}
+
+	formattedAPIKeys := []model.FormattedAPIKey{}
+	for _, k := range apiKeys {
+		formattedAPIKeys = append(formattedAPIKeys, k.FormatAsAPIKey())
+	}
+
+	out, err := json.Marshal(map[string][]model.FormattedAPIKey{
+		"api_keys": formattedAPIKeys,
+	})
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal API keys"))
+		return
+	}
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// HandleDeleteAPIKey revokes an API key given its id, it stops working straight away
+func HandleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	id := mux.Vars(r)["id"]
+	var apiKey model.APIKey
+	if err := db.Where("id = ?", id).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrorResponse(w, r, apierror.NotFound("API key does not exist"))
+			return
+		}
+		ErrorResponse(w, r, apierror.Internal(err, "failed to lookup API key"))
+		return
+	}
+
+	if err := db.Unscoped().Delete(&apiKey).Error; err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to delete API key"))
+		return
+	}
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write([]byte(`{"message":"deleted"}`))
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleCreateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"good", `{ "name": "door tablet", "role": "door_staff" }`, http.StatusOK},
+		{"missingName", `{ "role": "door_staff" }`, http.StatusUnprocessableEntity},
+		{"unknownRole", `{ "name": "door tablet", "role": "bouncer" }`, http.StatusUnprocessableEntity},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+
+			req, err := http.NewRequest("POST", "/api_keys", bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, c.expectedStatus, rr.Code)
+			if c.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var created model.FormattedAPIKey
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+			principal, err := auth.Authenticate(db, created.Key)
+			require.NoError(t, err)
+			assert.Equal(t, auth.RoleDoorStaff, principal.Role)
+			assert.Equal(t, "door tablet", principal.Name)
+		})
+	}
+}
+
+func TestHandleDeleteAPIKey(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+
+	key, err := auth.NewKey()
+	require.NoError(t, err)
+	apiKey, err := auth.CreateKey(db, "old tablet", auth.RoleDoorStaff, key)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api_keys", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), key)
+	assert.Contains(t, rr.Body.String(), apiKey.Prefix)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api_keys/1", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	_, err = auth.Authenticate(db, key)
+	assert.ErrorIs(t, err, auth.ErrInvalidKey)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api_keys/1", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
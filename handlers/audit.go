@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/model"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type listAuditEntriesResponse struct {
+	Entries    []model.FormattedAuditEntry `json:"entries"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
+
+// HandleListAuditEntries lists a page of the changes made to tables and reservations, newest first
+func HandleListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	// GET /audit?guest=string&table=int&actor=string&since=RFC3339&until=RFC3339&limit=int&cursor=string
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	// One more than the limit is loaded to tell if there is another page
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := audit.Search(requestDB(r), filter)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to load audit entries"))
+		return
+	}
+
+	res := listAuditEntriesResponse{Entries: []model.FormattedAuditEntry{}}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		res.NextCursor = encodeCursor(cursor{Sort: "audit", ID: entries[limit-1].ID})
+	}
+	for _, e := range entries {
+		res.Entries = append(res.Entries, e.FormatAsAuditEntry())
+	}
+	JSONResponse(w, r, http.StatusOK, res)
+}
+
+// parseAuditFilter reads the filters and paging options from a request for the audit log
+func parseAuditFilter(r *http.Request) (audit.Filter, error) {
+	values := r.URL.Query()
+	f := audit.Filter{
+		Guest: values.Get("guest"),
+		Actor: values.Get("actor"),
+		Limit: defaultPageSize,
+	}
+
+	if v := values.Get("table"); v != "" {
+		table, err := strconv.Atoi(v)
+		if err != nil {
+			return f, apierror.Validation("invalid table %q", v)
+		}
+		f.TableNumber = &table
+	}
+
+	for name, dest := range map[string]**time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := values.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return f, apierror.Validation("invalid %s %q, must be an RFC 3339 time", name, v)
+			}
+			*dest = &t
+		}
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return f, apierror.Validation("invalid limit %q, must be between 1 and %d", v, maxPageSize)
+		}
+		f.Limit = limit
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil || c.Sort != "audit" {
+			return f, apierror.Validation("invalid cursor")
+		}
+		f.BeforeID = c.ID
+	}
+
+	return f, nil
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+
+	planner, err := auth.NewKey()
+	require.NoError(t, err)
+	_, err = auth.CreateKey(db, "planner", auth.RolePlanner, planner)
+	require.NoError(t, err)
+	door, err := auth.NewKey()
+	require.NoError(t, err)
+	_, err = auth.CreateKey(db, "door", auth.RoleDoorStaff, door)
+	require.NoError(t, err)
+	admin, err := auth.NewKey()
+	require.NoError(t, err)
+	_, err = auth.CreateKey(db, "admin", auth.RoleAdmin, admin)
+	require.NoError(t, err)
+
+	send := func(key, method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, bytes.NewBuffer([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	require.Equal(t, http.StatusCreated, send(planner, "POST", "/v2/tables", `{ "number": 1, "seats": 5 }`).Code)
+	require.Equal(t, http.StatusCreated, send(planner, "POST", "/v2/reservations", `{ "name": "bob", "table": 1, "accompanying_guests": 1 }`).Code)
+	checkIn := send(door, "PUT", "/v2/arrivals/bob", `{ "accompanying_guests": 2 }`)
+	require.Equal(t, http.StatusCreated, checkIn.Code)
+	require.Equal(t, http.StatusNoContent, send(planner, "DELETE", "/v2/reservations/bob", "").Code)
+	// Failed changes aren't recorded
+	require.Equal(t, http.StatusConflict, send(planner, "POST", "/v2/tables", `{ "number": 1, "seats": 5 }`).Code)
+
+	list := func(query string) listAuditEntriesResponse {
+		rr := send(admin, "GET", "/audit?"+query, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var res listAuditEntriesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		return res
+	}
+
+	all := list("")
+	require.Len(t, all.Entries, 4)
+	actions := []string{}
+	for _, e := range all.Entries {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{"delete", "check_in", "create", "create"}, actions)
+
+	arrival := all.Entries[1]
+	assert.Equal(t, "door", arrival.Actor)
+	assert.Equal(t, "api_key", arrival.AuthMethod)
+	assert.Equal(t, "reservation", arrival.Resource)
+	assert.Equal(t, "bob", arrival.Guest)
+	assert.Equal(t, checkIn.Header().Get(requestid.Header), arrival.RequestID)
+	assert.JSONEq(t, `{"name":"bob","table":1,"accompanying_guests":1,"arrival_time":null,"version":1}`, string(arrival.Before))
+	var after map[string]interface{}
+	require.NoError(t, json.Unmarshal(arrival.After, &after))
+	assert.Equal(t, float64(2), after["accompanying_guests"])
+	assert.NotNil(t, after["arrival_time"])
+
+	deletion := all.Entries[0]
+	assert.Equal(t, "null", string(deletion.After))
+	assert.NotEqual(t, "null", string(deletion.Before))
+
+	assert.Len(t, list("guest=bob").Entries, 3)
+	assert.Len(t, list("table=1").Entries, 4)
+	assert.Len(t, list("actor=door").Entries, 1)
+	assert.Len(t, list("since=2000-01-01T00:00:00Z&until=2001-01-01T00:00:00Z").Entries, 0)
+
+	page := list("limit=3")
+	assert.Len(t, page.Entries, 3)
+	require.NotEmpty(t, page.NextCursor)
+	page = list("limit=3&cursor=" + page.NextCursor)
+	assert.Len(t, page.Entries, 1)
+	assert.Empty(t, page.NextCursor)
+
+	// Only admins can read the audit log
+	assert.Equal(t, http.StatusForbidden, send(planner, "GET", "/audit", "").Code)
+}
+
+func TestParseAuditFilter(t *testing.T) {
+	cases := []struct {
+		name        string
+		query       string
+		expectedErr string
+	}{
+		{"defaults", "", ""},
+		{"everything", "guest=bob&table=1&actor=door&since=2020-12-24T18:00:00Z&until=2020-12-25T02:00:00Z&limit=10", ""},
+		{"cursor", "cursor=" + encodeCursor(cursor{Sort: "audit", ID: 7}), ""},
+		{"badTable", "table=one", `invalid table "one"`},
+		{"badTime", "since=yesterday", `invalid since "yesterday", must be an RFC 3339 time`},
+		{"badLimit", "limit=0", `invalid limit "0", must be between 1 and 1000`},
+		{"badCursor", "cursor=foo", "invalid cursor"},
+		{"reservationCursor", "cursor=" + encodeCursor(cursor{Sort: "name", ID: 7}), "invalid cursor"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/audit?"+c.query, nil)
+			require.NoError(t, err)
+
+			f, err := parseAuditFilter(req)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotZero(t, f.Limit)
+		})
+	}
+}
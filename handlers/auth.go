@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/logging"
+	"net/http"
+	"strings"
+)
+
+// Authenticate works out who made a request from the API key or JWT in its Authorization: Bearer or X-API-Key header
+// and stores them in the request's context. Requests without credentials carry on anonymously, Require decides if that is
+// allowed, but credentials that don't check out are always refused
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentials := requestCredentials(r)
+		if credentials == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// API keys all start with the same prefix, anything else is treated as a token from the single sign on provider
+		if auth.JWT != nil && !strings.HasPrefix(credentials, auth.KeyPrefix) {
+			principal, err := auth.JWT.Validate(credentials)
+			if err != nil {
+				unauthorized(w, r, apierror.Unauthorized("%v", err))
+				return
+			}
+			logPrincipal(r, principal)
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+			return
+		}
+
+		if !databaseReady(w, r) {
+			return
+		}
+		principal, err := auth.Authenticate(database.FromContext(r.Context()), credentials)
+		if errors.Is(err, auth.ErrInvalidKey) {
+			unauthorized(w, r, apierror.Unauthorized("invalid API key"))
+			return
+		}
+		if err != nil {
+			ErrorResponse(w, r, apierror.Internal(err, "failed to check API key"))
+			return
+		}
+		logPrincipal(r, principal)
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Require only lets a request through to the handler if it was made by someone whose role has the permission. Saved
+// responses to idempotent requests are only replayed after the check, so they can't be used to get around it
+func Require(permission auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	handler := Idempotency(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if auth.Disabled {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			unauthorized(w, r, apierror.Unauthorized("authentication is required"))
+			return
+		}
+		if !principal.Role.Allows(permission) {
+			ErrorResponse(w, r, apierror.Forbidden("the %s role does not have the %s permission", principal.Role, permission))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// requestCredentials gets the API key or token from a request, if there is one
+func requestCredentials(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+			return strings.TrimSpace(header[7:])
+		}
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// logPrincipal adds who made a request to everything logged for it
+func logPrincipal(r *http.Request, principal auth.Principal) {
+	logging.AddField(r.Context(), "actor", principal.Name)
+	logging.AddField(r.Context(), "auth_method", principal.Method)
+}
+
+// unauthorized writes a 401 telling the client how to authenticate
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="guest-list"`)
+	ErrorResponse(w, r, err)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuthentication(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		role           auth.Role
+		key            string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+	}{
+		{"anonymous", "", "", "GET", "/guests", "", http.StatusUnauthorized},
+		{"invalidKey", "", "gl_nope", "GET", "/guests", "", http.StatusUnauthorized},
+		{"readOnlyCanList", auth.RoleReadOnly, "", "GET", "/guests", "", http.StatusOK},
+		{"readOnlyCantCheckIn", auth.RoleReadOnly, "", "PUT", "/guest/bob", `{ "accompanying_guests": 0 }`, http.StatusForbidden},
+		{"doorStaffCanCheckIn", auth.RoleDoorStaff, "", "PUT", "/guest/bob", `{ "accompanying_guests": 0 }`, http.StatusOK},
+		{"doorStaffCantCancel", auth.RoleDoorStaff, "", "DELETE", "/guest_list/bob", "", http.StatusForbidden},
+		{"doorStaffCantRemoveGuest", auth.RoleDoorStaff, "", "DELETE", "/guest/bob", "", http.StatusForbidden},
+		{"plannerCanRemoveGuest", auth.RolePlanner, "", "DELETE", "/guest/bob", "", http.StatusOK},
+		{"plannerCanCancel", auth.RolePlanner, "", "DELETE", "/guest_list/bob", "", http.StatusOK},
+		{"adminCanCancel", auth.RoleAdmin, "", "DELETE", "/guest_list/bob", "", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db := databasetest.New(t)
+			router := withDatabase(db, NewRouter())
+			load(t, db, `
+tables: [{number: 1, seats: 5}]
+reservations: [{name: bob, table: 1}]
+`)
+			keys := map[auth.Role]string{}
+			for _, role := range auth.Roles {
+				key, err := auth.NewKey()
+				require.NoError(t, err)
+				_, err = auth.CreateKey(db, string(role), role, key)
+				require.NoError(t, err)
+				keys[role] = key
+			}
+
+			req, err := http.NewRequest(c.method, c.url, bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+			if c.role != "" {
+				req.Header.Set("Authorization", "Bearer "+keys[c.role])
+			} else if c.key != "" {
+				req.Header.Set("X-API-Key", c.key)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			if c.expectedStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestJWTAuthentication(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "test",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(file, jwks, 0600))
+
+	auth.JWT, err = auth.NewJWTValidator(auth.JWTConfig{
+		Issuer:      "https://sso.example.com",
+		Audience:    "guest-list",
+		JWKSFile:    file,
+		RoleClaim:   "groups",
+		RoleMapping: map[string]auth.Role{"door": auth.RoleDoorStaff, "planners": auth.RolePlanner},
+	})
+	require.NoError(t, err)
+	defer func() { auth.JWT = nil }()
+
+	token := func(groups []string, exp time.Time) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":    "https://sso.example.com",
+			"aud":    "guest-list",
+			"sub":    "alice",
+			"exp":    exp.Unix(),
+			"groups": groups,
+		})
+		tok.Header["kid"] = "test"
+		signed, _ := tok.SignedString(key)
+		return signed
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		p, _ := auth.FromContext(r.Context())
+		_, _ = w.Write([]byte(p.Name))
+	}
+	router := mux.NewRouter()
+	router.Use(Authenticate)
+	router.HandleFunc("/tables", Require(auth.Read, ok)).Methods("GET")
+	router.HandleFunc("/guest/{name}", Require(auth.CheckIn, ok)).Methods("PUT")
+	router.HandleFunc("/table", Require(auth.Plan, ok)).Methods("POST")
+
+	hour := time.Now().Add(time.Hour)
+	cases := []struct {
+		name           string
+		token          string
+		method         string
+		url            string
+		expectedStatus int
+	}{
+		{"doorStaffCanRead", token([]string{"door"}, hour), "GET", "/tables", http.StatusOK},
+		{"doorStaffCanCheckIn", token([]string{"door"}, hour), "PUT", "/guest/bob", http.StatusOK},
+		{"doorStaffCantPlan", token([]string{"door"}, hour), "POST", "/table", http.StatusForbidden},
+		{"plannerCanPlan", token([]string{"door", "planners"}, hour), "POST", "/table", http.StatusOK},
+		{"unmappedGroup", token([]string{"admin"}, hour), "GET", "/tables", http.StatusUnauthorized},
+		{"expired", token([]string{"door"}, time.Now().Add(-time.Hour)), "GET", "/tables", http.StatusUnauthorized},
+		{"garbage", "not-a-token", "GET", "/tables", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest(c.method, c.url, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+c.token)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			if c.expectedStatus == http.StatusOK {
+				assert.Equal(t, "alice", rr.Body.String())
+			}
+			if c.expectedStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/backup"
+	"github.com/ctompkinson/guest-list/service"
+	"net/http"
+)
+
+// HandleBackup exports every table and reservation, including arrivals, as a versioned JSON document
+func HandleBackup(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	doc, err := backup.Export(db)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to export backup"))
+		return
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal backup"))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="guestlist-backup.json"`)
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// HandleRestore restores a document created by HandleBackup, it refuses to restore over an existing event
+func HandleRestore(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	var doc backup.Document
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+
+	if err := backup.Check(doc); err != nil {
+		ErrorResponse(w, r, apierror.Validation("invalid backup: %v", err))
+		return
+	}
+
+	if err := backup.Restore(db, doc); err != nil {
+		if errors.Is(err, backup.ErrNotEmpty) {
+			ErrorResponse(w, r, apierror.Conflict("%v", err))
+			return
+		}
+		ErrorResponse(w, r, apierror.Internal(err, "failed to restore backup"))
+		return
+	}
+	service.RefreshMetrics(db)
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write([]byte(`{"message":"restored"}`))
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleBackupAndRestore(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+
+	load(t, db, `
+tables: [{number: 1, seats: 5}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 1}
+  - {name: taylor, table: 1, accompanying_guests: 2}
+arrivals: [{name: bob}]
+`)
+
+	req, err := http.NewRequest("GET", "/backup", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	doc := rr.Body.Bytes()
+
+	// Restoring over an existing event isn't allowed
+	req, err = http.NewRequest("POST", "/restore", bytes.NewBuffer(doc))
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	empty, emptyRouter := newTestRouter(t)
+	req, err = http.NewRequest("POST", "/restore", bytes.NewBuffer(doc))
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	emptyRouter.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var reservations []model.Reservation
+	empty.Preload("Table").Order("guest").Find(&reservations)
+	require.Len(t, reservations, 2)
+	assert.Equal(t, "bob", reservations[0].Guest)
+	assert.Equal(t, 1, reservations[0].Table.Number)
+	assert.NotNil(t, reservations[0].ArrivalTime)
+	assert.Nil(t, reservations[1].ArrivalTime)
+}
+
+func TestHandleRestore_UnsupportedVersion(t *testing.T) {
+	t.Parallel()
+	_, router := newTestRouter(t)
+
+	req, err := http.NewRequest("POST", "/restore", bytes.NewBuffer([]byte(`{"schema_version": 99}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, `{"type":"urn:guest-list:problem:validation","title":"Unprocessable Entity","status":422,"detail":"invalid backup: unsupported backup schema version: got 99, expected at most 1","instance":"/restore","code":"validation"}`, strings.TrimSpace(rr.Body.String()))
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+const maxBulkOperations = 1000
+
+// errRolledBack is returned inside a bulk transaction to undo it once an operation has failed
+var errRolledBack = errors.New("bulk operation rolled back")
+
+// tableOperation is one change in a bulk request, seats is only used to create and update tables and shrink only to
+// update them
+type tableOperation struct {
+	Action string             `json:"action"`
+	Number int                `json:"number"`
+	Seats  *int               `json:"seats,omitempty"`
+	Shrink service.ShrinkMode `json:"shrink,omitempty"`
+}
+
+// tableOperationResult is what happened to one operation of a bulk request. When any operation fails nothing is
+// changed, operations that would have worked are reported as rolled_back
+type tableOperationResult struct {
+	Index  int                   `json:"index"`
+	Action string                `json:"action"`
+	Number int                   `json:"number"`
+	Status string                `json:"status"`
+	Table  *model.FormattedTable `json:"table,omitempty"`
+	Code   apierror.Kind         `json:"code,omitempty"`
+	Error  string                `json:"error,omitempty"`
+}
+
+type bulkTablesRequest struct {
+	Operations []tableOperation `json:"operations"`
+}
+
+type bulkTablesResponse struct {
+	Results []tableOperationResult `json:"results"`
+}
+
+// HandleV2BulkTables creates, updates and deletes many tables in one transaction, either every operation is applied
+// or none are. The response has a result for every operation in the order they were given
+func HandleV2BulkTables(w http.ResponseWriter, r *http.Request) {
+	// POST /v2/tables/bulk
+	// { "operations": [{ "action": "create|update|delete", "number": int, "seats": int, "shrink": "force|rebalance" }] }
+	var reqBody bulkTablesRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+
+	results, err := applyTableOperations(requestDB(r), reqBody.Operations)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	JSONResponse(w, r, http.StatusOK, bulkTablesResponse{Results: results})
+}
+
+// applyTableOperations runs every operation in a single transaction. Every operation is tried so the results show all
+// of the problems at once, but if any fail the transaction is rolled back and the error contains the results
+func applyTableOperations(db *gorm.DB, ops []tableOperation) ([]tableOperationResult, error) {
+	if len(ops) == 0 {
+		return nil, apierror.Validation("no operations given")
+	}
+	if len(ops) > maxBulkOperations {
+		return nil, apierror.Validation("at most %d operations can be given at once", maxBulkOperations)
+	}
+
+	results := make([]tableOperationResult, len(ops))
+	var firstErr error
+	failed := 0
+
+	err := service.Transaction(db, func(tx *gorm.DB) error {
+		for i, op := range ops {
+			results[i] = tableOperationResult{Index: i, Action: op.Action, Number: op.Number}
+
+			table, status, err := applyTableOperation(tx, op)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				failed++
+				results[i].Status = "failed"
+				results[i].Code = apierror.KindOf(err)
+				results[i].Error = apierror.Message(err)
+				continue
+			}
+
+			formatted := table.FormatAsTable()
+			results[i].Status = status
+			results[i].Table = &formatted
+		}
+
+		if firstErr != nil {
+			return errRolledBack
+		}
+		return nil
+	})
+
+	if firstErr != nil {
+		for i := range results {
+			if results[i].Status != "failed" {
+				results[i].Status = "rolled_back"
+			}
+		}
+		return nil, &apierror.Error{
+			Kind:   apierror.KindOf(firstErr),
+			Detail: fmt.Sprintf("%d of %d operations failed, no tables were changed", failed, len(ops)),
+			Extra:  results,
+			Err:    firstErr,
+		}
+	}
+	if err != nil {
+		return nil, apierror.Internal(err, "failed to apply operations")
+	}
+	return results, nil
+}
+
+// applyTableOperation runs a single operation, returning the table and its new status
+func applyTableOperation(db *gorm.DB, op tableOperation) (model.Table, string, error) {
+	switch op.Action {
+	case "create":
+		if op.Seats == nil {
+			return model.Table{}, "", apierror.Validation("seats is required to create a table")
+		}
+		table, err := service.CreateTable(db, service.NewTable{Number: op.Number, Seats: *op.Seats})
+		return table, "created", err
+	case "update":
+		if op.Seats == nil {
+			return model.Table{}, "", apierror.Validation("seats is required to update a table")
+		}
+		table, err := service.GetTable(db, op.Number)
+		if err != nil {
+			return table, "", err
+		}
+		table, _, err = service.UpdateTable(db, table, service.TableUpdate{Seats: op.Seats, Shrink: op.Shrink})
+		return table, "updated", err
+	case "delete":
+		table, err := service.GetTable(db, op.Number)
+		if err != nil {
+			return table, "", err
+		}
+		return table, "deleted", service.DeleteTable(db, table)
+	default:
+		return model.Table{}, "", apierror.Validation("unknown action %q, must be create, update or delete", op.Action)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleV2BulkTables(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedStatuses []string
+		expectedTables   map[int]int
+	}{
+		{
+			"good",
+			`{"operations":[{"action":"create","number":3,"seats":6},{"action":"update","number":1,"seats":8},{"action":"delete","number":2}]}`,
+			http.StatusOK,
+			[]string{"created", "updated", "deleted"},
+			map[int]int{1: 8, 3: 6},
+		},
+		{
+			"createThenUpdate",
+			`{"operations":[{"action":"create","number":3,"seats":6},{"action":"update","number":3,"seats":2}]}`,
+			http.StatusOK,
+			[]string{"created", "updated"},
+			map[int]int{1: 5, 2: 4, 3: 2},
+		},
+		{
+			"oneFails",
+			`{"operations":[{"action":"create","number":3,"seats":6},{"action":"delete","number":1}]}`,
+			http.StatusConflict,
+			[]string{"rolled_back", "failed"},
+			map[int]int{1: 5, 2: 4},
+		},
+		{
+			"shrinkBelowReserved",
+			`{"operations":[{"action":"update","number":1,"seats":1}]}`,
+			http.StatusConflict,
+			[]string{"failed"},
+			map[int]int{1: 5, 2: 4},
+		},
+		{
+			"unknownAction",
+			`{"operations":[{"action":"move","number":1}]}`,
+			http.StatusUnprocessableEntity,
+			[]string{"failed"},
+			map[int]int{1: 5, 2: 4},
+		},
+		{
+			"empty",
+			`{"operations":[]}`,
+			http.StatusUnprocessableEntity,
+			nil,
+			map[int]int{1: 5, 2: 4},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `
+tables: [{number: 1, seats: 5}, {number: 2, seats: 4}]
+reservations: [{name: bob, table: 1, accompanying_guests: 1}]
+`)
+
+			req, err := http.NewRequest("POST", "/v2/tables/bulk", bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, c.expectedStatus, rr.Code)
+
+			var results []tableOperationResult
+			if c.expectedStatus == http.StatusOK {
+				var out bulkTablesResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+				results = out.Results
+			} else if c.expectedStatuses != nil {
+				var out struct {
+					apierror.Problem
+					Errors []tableOperationResult `json:"errors"`
+				}
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+				results = out.Errors
+			}
+
+			var statuses []string
+			for _, result := range results {
+				statuses = append(statuses, result.Status)
+			}
+			assert.Equal(t, c.expectedStatuses, statuses)
+
+			var tables []model.Table
+			require.NoError(t, db.Find(&tables).Error)
+			seats := map[int]int{}
+			for _, table := range tables {
+				seats[table.Number] = table.Seats
+			}
+			assert.Equal(t, c.expectedTables, seats)
+		})
+	}
+}
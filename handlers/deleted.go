@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type v2DeletedTable struct {
+	model.FormattedTable
+	DeletedAt string `json:"deleted_at"`
+}
+
+type v2DeletedReservation struct {
+	v2Reservation
+	DeletedAt string `json:"deleted_at"`
+}
+
+// HandleV2ListDeletedTables lists the tables that have been deleted and can still be restored
+func HandleV2ListDeletedTables(w http.ResponseWriter, r *http.Request) {
+	tables, err := service.ListDeletedTables(requestDB(r))
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	formattedTables := []v2DeletedTable{}
+	for _, table := range tables {
+		formattedTables = append(formattedTables, v2DeletedTable{
+			FormattedTable: table.FormatAsTable(),
+			DeletedAt:      table.DeletedAt.Time.Format(time.RFC3339),
+		})
+	}
+	JSONResponse(w, r, http.StatusOK, map[string][]v2DeletedTable{"tables": formattedTables})
+}
+
+// HandleV2RestoreTable brings back the most recently deleted table with the number in the URL
+func HandleV2RestoreTable(w http.ResponseWriter, r *http.Request) {
+	// POST /v2/deleted/tables/{tableNumber}/restore
+	tableNumber, err := strconv.Atoi(mux.Vars(r)["tableNumber"])
+	if err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("failed to parse table number: %v", err))
+		return
+	}
+
+	db := requestDB(r)
+	table, err := service.GetDeletedTable(db, tableNumber)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	if table, err = service.RestoreTable(db, table); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v2/tables/%d", table.Number))
+	setETag(w, table.Version)
+	JSONResponse(w, r, http.StatusOK, table.FormatAsTable())
+}
+
+// HandleV2ListDeletedReservations lists the reservations that have been cancelled and can still be restored
+func HandleV2ListDeletedReservations(w http.ResponseWriter, r *http.Request) {
+	reservations, err := service.ListDeletedReservations(requestDB(r))
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	formattedReservations := []v2DeletedReservation{}
+	for _, res := range reservations {
+		formattedReservations = append(formattedReservations, v2DeletedReservation{
+			v2Reservation: newV2Reservation(res),
+			DeletedAt:     res.DeletedAt.Time.Format(time.RFC3339),
+		})
+	}
+	JSONResponse(w, r, http.StatusOK, map[string][]v2DeletedReservation{"reservations": formattedReservations})
+}
+
+// HandleV2RestoreReservation brings back a guest's cancelled reservation if their table still has room for them
+func HandleV2RestoreReservation(w http.ResponseWriter, r *http.Request) {
+	// POST /v2/deleted/reservations/{name}/restore
+	db := requestDB(r)
+	reservation, err := service.GetDeletedReservation(db, mux.Vars(r)["name"])
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	if reservation, err = service.RestoreReservation(db, reservation); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/v2/reservations/"+url.PathEscape(reservation.Guest))
+	setETag(w, reservation.Version)
+	JSONResponse(w, r, http.StatusOK, newV2Reservation(reservation))
+}
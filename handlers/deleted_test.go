@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandleV2RestoreReservation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		setup            func(t *testing.T, router http.Handler)
+		guest            string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{"restore", nil, "bob", http.StatusOK, `{"name":"bob","table":1,"accompanying_guests":1,"arrived_at":null}`},
+		{"notDeleted", nil, "alice", http.StatusNotFound, ""},
+		{"tableFull", func(t *testing.T, router http.Handler) {
+			send(t, router, "POST", "/v2/reservations", `{ "name": "carol", "table": 1, "accompanying_guests": 1 }`, http.StatusCreated)
+		}, "bob", http.StatusConflict, ""},
+		{"tableDeleted", func(t *testing.T, router http.Handler) {
+			send(t, router, "DELETE", "/v2/reservations/alice", "", http.StatusNoContent)
+			send(t, router, "DELETE", "/v2/tables/1", "", http.StatusNoContent)
+		}, "bob", http.StatusConflict, ""},
+		{"newReservationRefused", func(t *testing.T, router http.Handler) {
+			send(t, router, "POST", "/v2/reservations", `{ "name": "bob", "table": 1, "accompanying_guests": 0 }`, http.StatusConflict)
+		}, "bob", http.StatusOK, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `
+tables: [{number: 1, seats: 4}]
+reservations:
+  - {name: alice, table: 1, accompanying_guests: 1}
+  - {name: bob, table: 1, accompanying_guests: 1}
+`)
+
+			send(t, router, "DELETE", "/v2/reservations/bob", "", http.StatusNoContent)
+			rr := send(t, router, "GET", "/v2/deleted/reservations", "", http.StatusOK)
+			assert.Contains(t, rr.Body.String(), `"name":"bob"`)
+			if c.setup != nil {
+				c.setup(t, router)
+			}
+
+			rr = send(t, router, "POST", "/v2/deleted/reservations/"+c.guest+"/restore", "", c.expectedStatus)
+			if c.expectedResponse != "" {
+				assert.Equal(t, c.expectedResponse, strings.TrimSpace(rr.Body.String()))
+				assert.Equal(t, "/v2/reservations/bob", rr.Header().Get("Location"))
+				assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestHandleV2RestoreTable(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+	load(t, db, `tables: [{number: 1, seats: 4}]`)
+
+	send(t, router, "DELETE", "/v2/tables/1", "", http.StatusNoContent)
+	send(t, router, "GET", "/v2/tables/1", "", http.StatusNotFound)
+	rr := send(t, router, "GET", "/v2/deleted/tables", "", http.StatusOK)
+	assert.Contains(t, rr.Body.String(), `"number":1,"seats":4,"deleted_at":`)
+
+	rr = send(t, router, "POST", "/v2/deleted/tables/1/restore", "", http.StatusOK)
+	assert.Equal(t, `{"number":1,"seats":4}`, strings.TrimSpace(rr.Body.String()))
+	send(t, router, "GET", "/v2/tables/1", "", http.StatusOK)
+	send(t, router, "POST", "/v2/deleted/tables/1/restore", "", http.StatusNotFound)
+
+	// A table can't come back once its number has been reused
+	send(t, router, "DELETE", "/v2/tables/1", "", http.StatusNoContent)
+	send(t, router, "POST", "/v2/tables", `{ "number": 1, "seats": 2 }`, http.StatusCreated)
+	send(t, router, "POST", "/v2/deleted/tables/1/restore", "", http.StatusConflict)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/exporter"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// HandleExport streams reservations, arrivals or the per table seating plan as a CSV or XLSX spreadsheet
+func HandleExport(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+	// GET /export/{sheet}?format=csv|xlsx
+
+	sheet := mux.Vars(r)["sheet"]
+	if !exporter.IsSheet(sheet) {
+		ErrorResponse(w, r, apierror.NotFound("unknown export: %s", sheet))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exporter.FormatCSV
+	}
+	if !exporter.IsFormat(format) {
+		ErrorResponse(w, r, apierror.BadRequest("unsupported format: %s", format))
+		return
+	}
+
+	w.Header().Set("Content-Type", exporter.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, sheet, format))
+
+	// Once rows have been written the status can't be changed, so failures part way through just cut the file short
+	if err := exporter.Export(w, db, sheet, format); err != nil {
+		logging.FromContext(r.Context()).WithError(err).WithField("sheet", sheet).Error("failed to export")
+	}
+}
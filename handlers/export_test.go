@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleExport(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	formattedTime := now.Format("02/01/06 15:04")
+	cases := []struct {
+		name             string
+		url              string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			"reservations",
+			"/export/reservations",
+			http.StatusOK,
+			"name,table,accompanying_guests\nbob,1,1\ntaylor,1,2\n",
+		},
+
+		{
+			"arrivals",
+			"/export/arrivals?format=csv",
+			http.StatusOK,
+			"name,table,accompanying_guests,time_arrived\nbob,1,1," + formattedTime + "\n",
+		},
+		{
+			"seating",
+			"/export/seating",
+			http.StatusOK,
+			"table,seats,name,accompanying_guests,arrived\n1,5,bob,1,true\n1,5,taylor,2,false\n2,4,,,\n",
+		},
+		{
+			"unknownSheet",
+			"/export/foo",
+			http.StatusNotFound,
+			`{"type":"urn:guest-list:problem:not_found","title":"Not Found","status":404,"detail":"unknown export: foo","instance":"/export/foo","code":"not_found"}`,
+		},
+		{
+			"unknownFormat",
+			"/export/seating?format=pdf",
+			http.StatusBadRequest,
+			`{"type":"urn:guest-list:problem:bad_request","title":"Bad Request","status":400,"detail":"unsupported format: pdf","instance":"/export/seating","code":"bad_request"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+
+			load(t, db, fmt.Sprintf(`
+tables: [{number: 1, seats: 5}, {number: 2, seats: 4}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 1}
+  - {name: taylor, table: 1, accompanying_guests: 2}
+arrivals: [{name: bob, arrived_at: %s}]
+`, now.Format(time.RFC3339Nano)))
+
+			req, err := http.NewRequest("GET", c.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			assert.Equal(t, c.expectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleExport_Formula(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+
+	load(t, db, `
+tables: [{number: 1, seats: 5}]
+reservations: [{name: "@SUM(1+1)", table: 1}, {name: bob-smith, table: 1}]
+`)
+
+	req, err := http.NewRequest("GET", "/export/reservations", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "name,table,accompanying_guests\n'@SUM(1+1),1,0\nbob-smith,1,0\n", rr.Body.String())
+}
+
+func TestHandleExport_XLSX(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+
+	load(t, db, `
+tables: [{number: 1, seats: 5}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 1}
+  - {name: '=HYPERLINK("http://example.com")', table: 1}
+`)
+
+	req, err := http.NewRequest("GET", "/export/reservations?format=xlsx", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	f, err := excelize.OpenReader(rr.Body)
+	require.NoError(t, err)
+	rows, err := f.GetRows("reservations")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"name", "table", "accompanying_guests"},
+		{"'=HYPERLINK(\"http://example.com\")", "1", "0"},
+		{"bob", "1", "1"},
+	}, rows)
+}
@@ -2,12 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/apierror"
 	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
 	"net/http"
-	"time"
 )
 
 type guestArrivalRequest struct {
@@ -16,14 +15,18 @@ type guestArrivalRequest struct {
 type guestArrivalResponse struct {
 	Name string `json:"name"`
 }
+type listGuestsResponse struct {
+	Guests     []model.FormattedGuestArrival `json:"guests"`
+	NextCursor string                        `json:"next_cursor,omitempty"`
+}
 
 // HandleGuestArrival lets you signal that a guest has arrived at the party given a guests name and
 // the amount of guests they have shown up with
 func HandleGuestArrival(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
+	db := requestDB(r)
 	guestName := mux.Vars(r)["name"]
 	if guestName == "" {
-		ErrorResponse(w, http.StatusBadRequest, "unable to retrieve guest name from URL")
+		ErrorResponse(w, r, apierror.BadRequest("unable to retrieve guest name from URL"))
 		return
 	}
 
@@ -31,59 +34,50 @@ func HandleGuestArrival(w http.ResponseWriter, r *http.Request) {
 	var reqBody guestArrivalRequest
 	err := json.NewDecoder(r.Body).Decode(&reqBody)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("unable to parse body: %v", err))
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
 		return
 	}
-
-	// Find the reservation
-	var reservation model.Reservation
-	if err := db.Preload("Table").Where("guest = ?", guestName).First(&reservation).Error; err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("unable to find guest: %v", err))
+	if reqBody.AccompanyingGuests < 0 {
+		ErrorResponse(w, r, apierror.Validation("accompanying guests can't be negative"))
 		return
 	}
 
-	// Does our reservation have a different amount of seats?
-	if reservation.AccompanyingGuests != reqBody.AccompanyingGuests {
-		// Were going to check our own table by checking reservations, so we only want to check for new guests (minus overselves)
-		newGuests := reqBody.AccompanyingGuests - reservation.AccompanyingGuests
-
-		enoughSeats, err := areEnoughSeatsAvailable(reservation.Table, newGuests)
-		if err != nil {
-			ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to check available seats: %v", err))
-			return
-		}
-		if !enoughSeats {
-			ErrorResponse(w, http.StatusInternalServerError, "not enough seats available on selected table")
-			return
-		}
+	// Find the reservation
+	reservation, ok := findReservation(w, r)
+	if !ok {
+		return
 	}
 
-	// We can now update our reservation and add an arrival time
-	reservation.AccompanyingGuests = reqBody.AccompanyingGuests
-	now := time.Now()
-	reservation.ArrivalTime = &now
-
-	if err := db.Save(&reservation).Error; err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to save update to reservation: %v", err))
+	reservation, err = service.CheckIn(db, reservation, reqBody.AccompanyingGuests)
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
+	setETag(w, reservation.Version)
 
 	out, err := json.Marshal(guestArrivalResponse{Name: guestName})
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to marshal response: %v", err))
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal response"))
 		return
 	}
 	http.StatusText(http.StatusOK)
 	_, _ = w.Write(out)
 }
 
-// HandleListGuests lists guests that have arrived at the party and their arrival time
+// HandleListGuests lists the guests that have arrived at the party and their arrival time, only a page of them when a
+// limit or cursor is given, see parseReservationQuery for the filters
 func HandleListGuests(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
+	q, err := parseReservationQuery(r, 0)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	arrived := true
+	q.Arrived = &arrived
 
-	var reservations []model.Reservation
-	if err := db.Preload("Table").Where("arrival_time IS NOT NULL").Find(&reservations).Error; err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load reservations: %v", err))
+	reservations, next, err := listReservations(requestDB(r), q)
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
 
@@ -92,11 +86,12 @@ func HandleListGuests(w http.ResponseWriter, r *http.Request) {
 		formattedReservations = append(formattedReservations, res.FormatAsGuestArrival())
 	}
 
-	out, err := json.Marshal(map[string][]model.FormattedGuestArrival{
-		"guests": formattedReservations,
+	out, err := json.Marshal(listGuestsResponse{
+		Guests:     formattedReservations,
+		NextCursor: next,
 	})
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to marshal reservations: %v", err))
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal reservations"))
 		return
 	}
 
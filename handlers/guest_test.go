@@ -3,9 +3,6 @@ package handlers
 import (
 	"bytes"
 	"fmt"
-	"github.com/gorilla/mux"
-	"github.com/ctompkinson/guest-list/database"
-	"github.com/ctompkinson/guest-list/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -16,62 +13,54 @@ import (
 )
 
 func TestHandleListGuests(t *testing.T) {
-	database.Init()
-	db := database.Get()
+	t.Parallel()
 
 	now := time.Now()
 	formattedTime := now.Format("02/01/06 15:04")
 	fullOut := fmt.Sprintf(`{"guests":[{"name":"bob","accompanying_guests":1,"time_arrived":"%s"},{"name":"taylor","accompanying_guests":2,"time_arrived":"%s"}]}`, formattedTime, formattedTime)
 	cases := []struct {
-		name               string
-		url                string
-		expectedStatus     int
-		expectedResponse   string
-		createReservations []*model.Reservation
-		createTable        *model.Table
+		name             string
+		url              string
+		expectedStatus   int
+		expectedResponse string
+		fixture          string
 	}{
 		{
 			"good",
 			"/guests",
 			http.StatusOK,
 			fullOut,
-			[]*model.Reservation{
-				{Guest: "bob", AccompanyingGuests: 1, ArrivalTime: &now},
-				{Guest: "taylor", AccompanyingGuests: 2, ArrivalTime: &now},
-				{Guest: "scott", AccompanyingGuests: 2},
-			},
-			&model.Table{Number: 1, Seats: 5},
+			fmt.Sprintf(`
+tables: [{number: 1, seats: 5}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 1}
+  - {name: taylor, table: 1, accompanying_guests: 2}
+  - {name: scott, table: 1, accompanying_guests: 2}
+arrivals: [{name: bob, arrived_at: %[1]s}, {name: taylor, arrived_at: %[1]s}]
+`, now.Format(time.RFC3339Nano)),
 		},
 		{
 			"noReservation",
 			"/guests",
 			http.StatusOK,
 			`{"guests":[]}`,
-			nil,
-			nil,
+			"",
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
+			t.Parallel()
+			db, router := newTestRouter(t)
 
-			if c.createTable != nil {
-				db.Create(c.createTable)
-			}
-			if c.createReservations != nil {
-				for _, res := range c.createReservations {
-					res.Table = *c.createTable
-					db.Create(res)
-				}
+			if c.fixture != "" {
+				load(t, db, c.fixture)
 			}
 
 			req, err := http.NewRequest("GET", c.url, nil)
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/guests", HandleListGuests)
 			router.ServeHTTP(rr, req)
 
 			assert.Equal(t, c.expectedStatus, rr.Code)
@@ -82,17 +71,15 @@ func TestHandleListGuests(t *testing.T) {
 }
 
 func TestHandleGuestArrival(t *testing.T) {
-	database.Init()
-	db := database.Get()
+	t.Parallel()
 
 	cases := []struct {
-		name              string
-		url               string
-		body              string
-		expectedStatus    int
-		expectedResponse  string
-		createTable       *model.Table
-		createReservation *model.Reservation
+		name             string
+		url              string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+		fixture          string
 	}{
 		{
 			"good",
@@ -100,47 +87,61 @@ func TestHandleGuestArrival(t *testing.T) {
 			`{ "accompanying_guests": 1 }`,
 			http.StatusOK,
 			`{"name":"bob"}`,
-			&model.Table{Number: 1, Seats: 6},
-			&model.Reservation{Guest: "bob", AccompanyingGuests: 1},
+			`
+tables: [{number: 1, seats: 6}]
+reservations: [{name: bob, table: 1, accompanying_guests: 1}]
+`,
 		},
 		{
 			"noData",
 			"/guest/bob",
 			``,
 			http.StatusBadRequest,
-			`{"message":"unable to parse body: EOF"}`,
-			nil,
-			nil,
+			`{"type":"urn:guest-list:problem:bad_request","title":"Bad Request","status":400,"detail":"unable to parse body: EOF","instance":"/guest/bob","code":"bad_request"}`,
+			"",
+		},
+		{
+			"noReservation",
+			"/guest/bob",
+			`{ "accompanying_guests": 1 }`,
+			http.StatusNotFound,
+			`{"type":"urn:guest-list:problem:not_found","title":"Not Found","status":404,"detail":"guest does not have a reservation","instance":"/guest/bob","code":"not_found"}`,
+			"",
+		},
+		{
+			"negativeGuests",
+			"/guest/bob",
+			`{ "accompanying_guests": -1 }`,
+			http.StatusUnprocessableEntity,
+			`{"type":"urn:guest-list:problem:validation","title":"Unprocessable Entity","status":422,"detail":"accompanying guests can't be negative","instance":"/guest/bob","code":"validation"}`,
+			"",
 		},
 		{
 			"noSeats",
 			"/guest/bob",
 			`{ "accompanying_guests": 5 }`,
-			http.StatusInternalServerError,
-			`{"message":"not enough seats available on selected table"}`,
-			&model.Table{Number: 1, Seats: 5},
-			&model.Reservation{Guest: "bob", AccompanyingGuests: 4},
+			http.StatusConflict,
+			`{"type":"urn:guest-list:problem:capacity_exceeded","title":"Conflict","status":409,"detail":"not enough seats available on selected table","instance":"/guest/bob","code":"capacity_exceeded"}`,
+			`
+tables: [{number: 1, seats: 5}]
+reservations: [{name: bob, table: 1, accompanying_guests: 4}]
+`,
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
+			t.Parallel()
+			db, router := newTestRouter(t)
 
-			if c.createTable != nil {
-				db.Create(c.createTable)
-			}
-			if c.createReservation != nil {
-				c.createReservation.Table = *c.createTable
-				db.Create(c.createReservation)
+			if c.fixture != "" {
+				load(t, db, c.fixture)
 			}
 
 			req, err := http.NewRequest("PUT", c.url, bytes.NewBuffer([]byte(c.body)))
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/guest/{name}", HandleGuestArrival)
 			router.ServeHTTP(rr, req)
 
 			assert.Equal(t, c.expectedStatus, rr.Code)
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/logging"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds the database checks so a hung connection fails the probe rather than stalling it
+const readinessTimeout = 2 * time.Second
+
+// withoutDatabase are the routes that work before the database is connected
+var withoutDatabase = map[string]bool{
+	"/health/live":  true,
+	"/health/ready": true,
+	"/openapi.yaml": true,
+	"/openapi.json": true,
+	"/docs":         true,
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// RequireDatabase turns requests away while the server is still connecting to the database. The health checks and
+// documentation don't need it, and requests without credentials are left for Require to refuse
+func RequireDatabase(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.FromContext(r.Context()); (ok || auth.Disabled) && !withoutDatabase[routeTemplate(r)] {
+			if !databaseReady(w, r) {
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// databaseReady answers with a 503 and returns false while the server is still connecting to the database
+func databaseReady(w http.ResponseWriter, r *http.Request) bool {
+	if database.FromContext(r.Context()) != nil {
+		return true
+	}
+	w.Header().Set("Retry-After", "5")
+	ErrorResponse(w, r, apierror.Unavailable("the server is still connecting to the database"))
+	return false
+}
+
+// HandleLiveness says the server is running, it doesn't look at the database so a slow database never gets a live
+// server restarted
+func HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	// GET /health/live
+	JSONResponse(w, r, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// HandleReadiness says whether the server can handle requests, the database has to be reachable and migrated. The
+// route is public so why a check failed is only logged
+func HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	// GET /health/ready
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	res := healthResponse{Status: "ready", Checks: map[string]string{"database": "ok", "migrations": "ok"}}
+	if err := database.Ping(ctx); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Warn("readiness check failed to reach the database")
+		res.Status = "unavailable"
+		res.Checks["database"] = "unreachable"
+		res.Checks["migrations"] = "unknown"
+	} else if err := database.CheckMigrations(ctx); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Warn("readiness check found the schema is behind")
+		res.Status = "unavailable"
+		res.Checks["migrations"] = "behind"
+	}
+
+	status := http.StatusOK
+	if res.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	JSONResponse(w, r, status, res)
+}
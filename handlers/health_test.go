@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleHealth(t *testing.T) {
+	t.Parallel()
+	_, router := newTestRouter(t)
+
+	cases := []struct {
+		name             string
+		url              string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{"live", "/health/live", http.StatusOK, `{"status":"ok"}`},
+		{"ready", "/health/ready", http.StatusOK, `{"status":"ready","checks":{"database":"ok","migrations":"ok"}}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			req, err := http.NewRequest("GET", c.url, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			assert.JSONEq(t, c.expectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleReadinessBehindSchema(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+	require.NoError(t, db.Exec("ALTER TABLE audit_entries DROP COLUMN request_id").Error)
+
+	req, err := http.NewRequest("GET", "/health/ready", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{"status":"unavailable","checks":{"database":"ok","migrations":"behind"}}`, rr.Body.String())
+}
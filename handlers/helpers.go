@@ -2,43 +2,78 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
 	"github.com/ctompkinson/guest-list/database"
-	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"net/http"
+	"strings"
 )
 
-type errorResponse struct {
-	Message string `json:"message"`
+// ErrorResponse writes an RFC 7807 problem+json response for any error, the status code is decided by the
+// apierror kind of the error. Server errors are logged as errors along with their cause, anything else as a warning
+func ErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	problem := apierror.ToProblem(err, r.URL.Path)
+	res, _ := json.Marshal(problem)
+
+	log := logging.FromContext(r.Context()).WithFields(logrus.Fields{"status": problem.Status, "code": problem.Code})
+	if problem.Status >= http.StatusInternalServerError {
+		log.WithError(err).Error("request failed")
+	} else {
+		log.Warn(problem.Detail)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(res)
 }
 
-// ErrorResponse generates a json response given a http status code and a message
-func ErrorResponse(w http.ResponseWriter, code int, message string) {
-	res, _ := json.Marshal(errorResponse{Message: message})
-	http.Error(w, string(res), code)
+// requestDB gets the database with the request's context, so changes made with it know who made them and which
+// request they were part of
+func requestDB(r *http.Request) *gorm.DB {
+	return database.FromContext(r.Context()).WithContext(r.Context())
 }
 
-// areEnoughSeatsAvailable checks if enough seats are available on a table
-// ensure that newGuests is the exact amount of people who you want to put on the table
-func areEnoughSeatsAvailable(table model.Table, newGuests int) (bool, error) {
-	db := database.Get()
+// JSONResponse marshals v and writes it with the given status code
+func JSONResponse(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal response"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+}
 
-	// Lets all reservations for our table
-	var existingTableReservations []model.Reservation
-	result := db.Where("table_id = ?", table.ID).Find(&existingTableReservations)
-	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return false, result.Error
+// etag formats the version of a table or reservation as a strong entity tag
+func etag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// setETag tells the client which version of a table or reservation it was sent
+func setETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// checkIfMatch returns a precondition failed error when the request has an If-Match header that doesn't contain the
+// current version, requests without one are always allowed
+func checkIfMatch(r *http.Request, version uint, what string) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return nil
 	}
 
-	seatsUsed := 0
-	if len(existingTableReservations) > 0 {
-		for _, r := range existingTableReservations {
-			seatsUsed = seatsUsed + r.AccompanyingGuests + 1 // Plus one to account the primary guest
+	current := etag(version)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return nil
 		}
 	}
-	if (table.Seats - seatsUsed) >= newGuests {
-		return true, nil
-	}
-	return false, nil
+	return apierror.PreconditionFailed("%s has been changed, it is now at version %s", what, current)
 }
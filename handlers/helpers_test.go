@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/fixtures"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorResponse(t *testing.T) {
+	cases := []struct {
+		name             string
+		err              error
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			"badRequest",
+			apierror.BadRequest("unable to parse body: EOF"),
+			http.StatusBadRequest,
+			`{"type":"urn:guest-list:problem:bad_request","title":"Bad Request","status":400,"detail":"unable to parse body: EOF","instance":"/guest/bob","code":"bad_request"}`,
+		},
+		{
+			"validation",
+			apierror.Validation("accompanying guests can't be negative"),
+			http.StatusUnprocessableEntity,
+			`{"type":"urn:guest-list:problem:validation","title":"Unprocessable Entity","status":422,"detail":"accompanying guests can't be negative","instance":"/guest/bob","code":"validation"}`,
+		},
+		{
+			"notFound",
+			apierror.NotFound("guest does not have a reservation"),
+			http.StatusNotFound,
+			`{"type":"urn:guest-list:problem:not_found","title":"Not Found","status":404,"detail":"guest does not have a reservation","instance":"/guest/bob","code":"not_found"}`,
+		},
+		{
+			"conflict",
+			apierror.Conflict("the guest already has a reservation"),
+			http.StatusConflict,
+			`{"type":"urn:guest-list:problem:conflict","title":"Conflict","status":409,"detail":"the guest already has a reservation","instance":"/guest/bob","code":"conflict"}`,
+		},
+		{
+			"capacityExceeded",
+			apierror.CapacityExceeded("not enough seats available on selected table"),
+			http.StatusConflict,
+			`{"type":"urn:guest-list:problem:capacity_exceeded","title":"Conflict","status":409,"detail":"not enough seats available on selected table","instance":"/guest/bob","code":"capacity_exceeded"}`,
+		},
+		{
+			"internal",
+			apierror.Internal(errors.New("connection refused"), "failed to lookup reservation"),
+			http.StatusInternalServerError,
+			`{"type":"urn:guest-list:problem:internal","title":"Internal Server Error","status":500,"detail":"failed to lookup reservation","instance":"/guest/bob","code":"internal"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest("PUT", "/guest/bob", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			ErrorResponse(rr, req, c.err)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+			assert.Equal(t, c.expectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		ifMatch string
+		ok      bool
+	}{
+		{"missing", "", true},
+		{"current", `"3"`, true},
+		{"anyOf", `"2", "3"`, true},
+		{"wildcard", "*", true},
+		{"stale", `"2"`, false},
+		{"weak", `W/"3"`, false},
+		{"unquoted", "3", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/table/1", nil)
+			if c.ifMatch != "" {
+				req.Header.Set("If-Match", c.ifMatch)
+			}
+
+			err := checkIfMatch(req, 3, "table")
+			if c.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, apierror.KindPreconditionFailed, apierror.KindOf(err))
+			}
+		})
+	}
+}
+
+// newTestRouter gives the test a database of its own and returns it with a router serving every API route. Requests
+// without credentials are made as an admin, so tests only set them when authentication is what is being tested
+func newTestRouter(t *testing.T) (*gorm.DB, http.Handler) {
+	db := databasetest.New(t)
+	router := withDatabase(db, NewRouter())
+	return db, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestCredentials(r) == "" {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), auth.Principal{Name: "test", Role: auth.RoleAdmin, Method: "api_key"}))
+		}
+		router.ServeHTTP(w, r)
+	})
+}
+
+// load creates the tables, reservations and arrivals described by a YAML fixture, see fixtures.Fixture for the format
+func load(t *testing.T, db *gorm.DB, fixture string) {
+	t.Helper()
+	require.NoError(t, fixtures.Load(db, fixtures.MustParse(fixture)))
+}
+
+// withDatabase serves requests with handler using db rather than the shared connection
+func withDatabase(db *gorm.DB, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(database.WithDB(r.Context(), db)))
+	})
+}
+
+// send makes a request to a router and checks its status
+func send(t *testing.T, router http.Handler, method, url, body string, expectedStatus int) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, url, bytes.NewBuffer([]byte(body)))
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, expectedStatus, rr.Code, "%s %s: %s", method, url, rr.Body.String())
+	return rr
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/idempotency"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/requestid"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Idempotency makes POST, PUT, PATCH and DELETE requests safe to retry. When a request has an Idempotency-Key header
+// the first response to it is saved and sent back for every retry by the same caller with the same key, method, path
+// and body, without running the handler again. Require runs it once the caller is known to be allowed to make the
+// request. Server errors, authentication failures and responses marked Cache-Control: no-store, such as those with a
+// new secret in them, aren't saved so they can be retried
+func Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotency.Header)
+		if key == "" || r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			ErrorResponse(w, r, apierror.BadRequest("%s can't be longer than %d characters", idempotency.Header, idempotency.MaxKeyLength))
+			return
+		}
+
+		if !databaseReady(w, r) {
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			ErrorResponse(w, r, apierror.BadRequest("unable to read body: %v", err))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		db := database.FromContext(r.Context())
+		record, replay, err := idempotency.Begin(db, key, requestPrincipal(r), r.Method, r.URL.RequestURI(), idempotency.Fingerprint(body))
+		if errors.Is(err, idempotency.ErrMismatch) {
+			ErrorResponse(w, r, apierror.Validation("%v", err))
+			return
+		}
+		if errors.Is(err, idempotency.ErrInProgress) {
+			ErrorResponse(w, r, apierror.Conflict("%v", err))
+			return
+		}
+		if err != nil {
+			ErrorResponse(w, r, apierror.Internal(err, "failed to check idempotency key"))
+			return
+		}
+
+		if replay {
+			header, err := idempotency.Headers(record)
+			if err != nil {
+				ErrorResponse(w, r, apierror.Internal(err, "failed to read saved response"))
+				return
+			}
+			for name, values := range header {
+				// The retry keeps its own request ID so it can be told apart from the first request
+				if name == requestid.Header {
+					continue
+				}
+				w.Header()[name] = values
+			}
+			w.Header().Set(idempotency.ReplayedHeader, "true")
+			w.WriteHeader(record.StatusCode)
+			_, _ = w.Write(record.Body)
+			return
+		}
+
+		// If the handler panics the key is released so the request can be retried
+		saved := false
+		defer func() {
+			if !saved {
+				if err := idempotency.Release(db, record); err != nil {
+					logging.FromContext(r.Context()).WithError(err).Error("failed to release idempotency key")
+				}
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= 500 || rec.status == http.StatusUnauthorized || rec.status == http.StatusForbidden {
+			return
+		}
+		if strings.Contains(w.Header().Get("Cache-Control"), "no-store") {
+			return
+		}
+		if err := idempotency.Complete(db, record, rec.status, w.Header(), rec.body.Bytes()); err != nil {
+			logging.FromContext(r.Context()).WithError(err).Error("failed to save idempotent response")
+			return
+		}
+		saved = true
+	})
+}
+
+// requestPrincipal identifies who made a request so a saved response is never replayed to anyone else, it is empty when
+// authentication is turned off
+func requestPrincipal(r *http.Request) string {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	return principal.Method + ":" + principal.Name + ":" + string(principal.Role)
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/idempotency"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	// sendAs makes a request with an API key, or as the test router's admin when apiKey is empty
+	sendAs := func(router http.Handler, apiKey, key, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(body)))
+		require.NoError(t, err)
+		if key != "" {
+			req.Header.Set(idempotency.Header, key)
+		}
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	send := func(router http.Handler, key, url, body string) *httptest.ResponseRecorder {
+		return sendAs(router, "", key, url, body)
+	}
+	newKey := func(db *gorm.DB, name string, role auth.Role) string {
+		key, err := auth.NewKey()
+		require.NoError(t, err)
+		_, err = auth.CreateKey(db, name, role, key)
+		require.NoError(t, err)
+		return key
+	}
+
+	t.Run("retryIsReplayed", func(t *testing.T) {
+		t.Parallel()
+		db, router := newTestRouter(t)
+		load(t, db, `tables: [{number: 1, seats: 5}]`)
+
+		first := send(router, "abc", "/guest_list/bob", `{ "table": 1, "accompanying_guests": 1 }`)
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+		retry := send(router, "abc", "/guest_list/bob", `{ "table": 1, "accompanying_guests": 1 }`)
+		assert.Equal(t, http.StatusOK, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+
+		var count int64
+		db.Model(&model.Reservation{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("withoutKey", func(t *testing.T) {
+		t.Parallel()
+		db, router := newTestRouter(t)
+		load(t, db, `tables: [{number: 1, seats: 5}]`)
+
+		assert.Equal(t, http.StatusOK, send(router, "", "/guest_list/bob", `{ "table": 1 }`).Code)
+		assert.Equal(t, http.StatusConflict, send(router, "", "/guest_list/bob", `{ "table": 1 }`).Code)
+	})
+
+	t.Run("differentBody", func(t *testing.T) {
+		t.Parallel()
+		db, router := newTestRouter(t)
+		load(t, db, `tables: [{number: 1, seats: 5}]`)
+
+		assert.Equal(t, http.StatusOK, send(router, "abc", "/guest_list/bob", `{ "table": 1 }`).Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, send(router, "abc", "/guest_list/bob", `{ "table": 1, "accompanying_guests": 2 }`).Code)
+	})
+
+	t.Run("differentPath", func(t *testing.T) {
+		t.Parallel()
+		db, router := newTestRouter(t)
+		load(t, db, `tables: [{number: 1, seats: 5}]`)
+
+		assert.Equal(t, http.StatusOK, send(router, "abc", "/guest_list/bob", `{ "table": 1 }`).Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, send(router, "abc", "/guest_list/alice", `{ "table": 1 }`).Code)
+	})
+
+	t.Run("errorsAreReplayed", func(t *testing.T) {
+		t.Parallel()
+		db, router := newTestRouter(t)
+
+		first := send(router, "abc", "/guest_list/bob", `{ "table": 1 }`)
+		require.Equal(t, http.StatusNotFound, first.Code)
+
+		load(t, db, `tables: [{number: 1, seats: 5}]`)
+		retry := send(router, "abc", "/guest_list/bob", `{ "table": 1 }`)
+		assert.Equal(t, http.StatusNotFound, retry.Code)
+		assert.Equal(t, "application/problem+json", retry.Header().Get("Content-Type"))
+	})
+	t.Run("onlyReplayedToTheSameCaller", func(t *testing.T) {
+		t.Parallel()
+		db, router := newTestRouter(t)
+		readOnly := newKey(db, "viewer", auth.RoleReadOnly)
+		otherAdmin := newKey(db, "other", auth.RoleAdmin)
+
+		load(t, db, `tables: [{number: 1, seats: 5}]`)
+
+		first := send(router, "abc", "/guest_list/bob", `{ "table": 1 }`)
+		require.Equal(t, http.StatusOK, first.Code)
+
+		// The permission is checked before anything is replayed
+		assert.Equal(t, http.StatusForbidden, sendAs(router, readOnly, "abc", "/guest_list/bob", `{ "table": 1 }`).Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, sendAs(router, otherAdmin, "abc", "/guest_list/bob", `{ "table": 1 }`).Code)
+	})
+
+	t.Run("secretsAreNotSaved", func(t *testing.T) {
+		t.Parallel()
+		db, router := newTestRouter(t)
+
+		first := send(router, "abc", "/api_keys", `{ "name": "ci", "role": "read_only" }`)
+		require.Equal(t, http.StatusOK, first.Code)
+		retry := send(router, "abc", "/api_keys", `{ "name": "ci", "role": "read_only" }`)
+		require.Equal(t, http.StatusOK, retry.Code)
+		assert.Empty(t, retry.Header().Get(idempotency.ReplayedHeader))
+		assert.NotEqual(t, first.Body.String(), retry.Body.String())
+
+		var count int64
+		db.Model(&model.IdempotencyKey{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("authErrorsAreNotSaved", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		router := withDatabase(databasetest.New(t), Idempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusForbidden)
+		})))
+
+		assert.Equal(t, http.StatusForbidden, send(router, "abc", "/guest_list/bob", `{ "table": 1 }`).Code)
+		assert.Equal(t, http.StatusForbidden, send(router, "abc", "/guest_list/bob", `{ "table": 1 }`).Code)
+		assert.Equal(t, 2, calls)
+	})
+}
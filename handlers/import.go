@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/importer"
+	"net/http"
+)
+
+const maxImportSize = 32 << 20
+
+// HandleImport bulk imports tables and reservations from CSV files uploaded as the multipart form fields
+// "tables" (table,seats) and "guest_list" (name,table,accompanying_guests). Every row is validated first,
+// with ?dry_run=true only the report is returned, otherwise everything is created in one transaction
+func HandleImport(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+	// POST /import?dry_run=true
+
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse form: %v", err))
+		return
+	}
+
+	var plan importer.Plan
+	report := importer.Report{DryRun: r.URL.Query().Get("dry_run") == "true", Errors: []importer.RowError{}}
+	found := false
+
+	if file, _, err := r.FormFile(importer.TablesFile); err == nil {
+		defer file.Close()
+		rows, errs := importer.ParseTables(file)
+		plan.Tables = rows
+		report.Errors = append(report.Errors, errs...)
+		found = true
+	} else if !errors.Is(err, http.ErrMissingFile) {
+		ErrorResponse(w, r, apierror.BadRequest("unable to read tables file: %v", err))
+		return
+	}
+
+	if file, _, err := r.FormFile(importer.ReservationsFile); err == nil {
+		defer file.Close()
+		rows, errs := importer.ParseReservations(file)
+		plan.Reservations = rows
+		report.Errors = append(report.Errors, errs...)
+		found = true
+	} else if !errors.Is(err, http.ErrMissingFile) {
+		ErrorResponse(w, r, apierror.BadRequest("unable to read guest list file: %v", err))
+		return
+	}
+
+	if !found {
+		ErrorResponse(w, r, apierror.BadRequest("no tables or guest_list file was uploaded"))
+		return
+	}
+
+	// Only look at the database if every row could be read
+	if len(report.Errors) == 0 {
+		errs, err := importer.Validate(db, plan)
+		if err != nil {
+			ErrorResponse(w, r, apierror.Internal(err, "failed to validate import"))
+			return
+		}
+		report.Errors = errs
+	}
+	report.Valid = len(report.Errors) == 0
+
+	if report.Valid && !report.DryRun {
+		tables, reservations, err := importer.Apply(db, plan)
+		if errors.Is(err, importer.ErrInvalid) {
+			ErrorResponse(w, r, apierror.Conflict("the guest list changed during the import, try again"))
+			return
+		}
+		if err != nil {
+			ErrorResponse(w, r, apierror.Internal(err, "failed to apply import"))
+			return
+		}
+		report.TablesCreated = len(tables)
+		report.ReservationsCreated = len(reservations)
+	}
+
+	if !report.Valid {
+		ErrorResponse(w, r, &apierror.Error{
+			Kind:   apierror.KindValidation,
+			Detail: fmt.Sprintf("import contains %d invalid rows", len(report.Errors)),
+			Extra:  report.Errors,
+		})
+		return
+	}
+
+	out, err := json.Marshal(report)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal report"))
+		return
+	}
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/importer"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHandleImport(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name                 string
+		url                  string
+		tables               string
+		guestList            string
+		expectedStatus       int
+		expectedErrors       []importer.RowError
+		expectedTables       int64
+		expectedReservations int64
+	}{
+		{
+			"good",
+			"/import",
+			"table,seats\n2,4\n",
+			"name,table,accompanying_guests\nbob,1,1\ntaylor,2,3\n",
+			http.StatusOK,
+			[]importer.RowError{},
+			2,
+			2,
+		},
+		{
+			"dryRun",
+			"/import?dry_run=true",
+			"table,seats\n2,4\n",
+			"name,table,accompanying_guests\nbob,1,1\n",
+			http.StatusOK,
+			[]importer.RowError{},
+			1,
+			0,
+		},
+		{
+			"invalid",
+			"/import",
+			"table,seats\n1,4\n",
+			"name,table,accompanying_guests\nbob,3,1\ntaylor,1,4\ntaylor,1,0\n",
+			http.StatusUnprocessableEntity,
+			[]importer.RowError{
+				{File: "tables", Line: 2, Message: "a table exists with that number already"},
+				{File: "guest_list", Line: 2, Message: "table 3 does not exist"},
+				{File: "guest_list", Line: 3, Message: "not enough seats available on selected table"},
+				{File: "guest_list", Line: 4, Message: "guest taylor is duplicated on line 3"},
+			},
+			1,
+			0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `tables: [{number: 1, seats: 2}]`)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, importRequest(t, c.url, c.tables, c.guestList))
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			var report importer.Report
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+			assert.Equal(t, c.expectedErrors, report.Errors)
+
+			var tables, reservations int64
+			db.Model(&model.Table{}).Count(&tables)
+			db.Model(&model.Reservation{}).Count(&reservations)
+			assert.Equal(t, c.expectedTables, tables)
+			assert.Equal(t, c.expectedReservations, reservations)
+		})
+	}
+}
+
+// Not parallel, the test receiver listens on localhost so private webhook targets are allowed while it runs
+func TestHandleImport_Webhooks(t *testing.T) {
+	webhooks.AllowPrivate = true
+	defer func() { webhooks.AllowPrivate = false }()
+	db, router := newTestRouter(t)
+
+	var mu sync.Mutex
+	var events []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhooks.Event
+		if json.NewDecoder(r.Body).Decode(&e) == nil {
+			mu.Lock()
+			events = append(events, e.Type)
+			mu.Unlock()
+		}
+	}))
+	defer receiver.Close()
+	db.Create(&model.WebhookSubscription{URL: receiver.URL, Secret: "foo"})
+
+	// Nothing is sent for a dry run, or an import that was refused, as neither commits anything
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, importRequest(t, "/import?dry_run=true", "table,seats\n1,4\n", "name,table,accompanying_guests\nbob,1,1\n"))
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, importRequest(t, "/import", "table,seats\n1,4\n", "name,table,accompanying_guests\nbob,1,4\n"))
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	webhooks.Wait()
+	assert.Empty(t, events)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, importRequest(t, "/import", "table,seats\n1,4\n", "name,table,accompanying_guests\nbob,1,1\n"))
+	require.Equal(t, http.StatusOK, rr.Code)
+	webhooks.Wait()
+	assert.ElementsMatch(t, []string{webhooks.EventTableCreated, webhooks.EventReservationCreated}, events)
+}
+
+// importRequest uploads the tables and guest list CSV files to url
+func importRequest(t *testing.T, url, tables, guestList string) *http.Request {
+	t.Helper()
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("tables", "tables.csv")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte(tables))
+	part, err = form.CreateFormFile("guest_list", "guest_list.csv")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte(guestList))
+	require.NoError(t, form.Close())
+
+	req, err := http.NewRequest("POST", url, body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
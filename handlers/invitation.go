@@ -2,10 +2,7 @@ package handlers
 
 import (
 	"bytes"
-	"fmt"
-	"github.com/gorilla/mux"
-	"github.com/ctompkinson/guest-list/database"
-	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/apierror"
 	"github.com/ctompkinson/guest-list/templates"
 	"html/template"
 	"net/http"
@@ -13,22 +10,14 @@ import (
 
 // HandleCreateInvitation creates a HTML invitation for a given guest
 func HandleCreateInvitation(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
-	guestName := mux.Vars(r)["name"]
-	if guestName == "" {
-		ErrorResponse(w, http.StatusBadRequest, "unable to retrieve guest name from URL")
-		return
-	}
-
-	var reservation model.Reservation
-	if err := db.Preload("Table").Where("guest = ?", guestName).First(&reservation).Error; err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("unable to find reservation: %v", err))
+	reservation, ok := findReservation(w, r)
+	if !ok {
 		return
 	}
 
 	tmpl, err := template.New("invitation").Parse(templates.InvitationTemplate)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load template: %v", err))
+		ErrorResponse(w, r, apierror.Internal(err, "failed to load template"))
 		return
 	}
 
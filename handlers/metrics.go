@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics counts every request and times it, labelled by the route template rather than the path so guest names and
+// table numbers don't each get their own series
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := routeTemplate(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// HandleMetrics serves the metrics for Prometheus to scrape
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics.Handler().ServeHTTP(w, r)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/metrics"
+	"github.com/ctompkinson/guest-list/service"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/metrics_test/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}).Methods("GET")
+	router.HandleFunc("/metrics", HandleMetrics).Methods("GET")
+
+	requests := metrics.HTTPRequests.WithLabelValues("GET", "/metrics_test/{name}", "418")
+	before := testutil.ToFloat64(requests)
+	for _, name := range []string{"alice", "bob"} {
+		req, err := http.NewRequest("GET", "/metrics_test/"+name, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, before+2, testutil.ToFloat64(requests))
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `guestlist_http_requests_total{method="GET",route="/metrics_test/{name}",status="418"}`)
+	assert.Contains(t, rr.Body.String(), `guestlist_http_request_duration_seconds_bucket{method="GET",route="/metrics_test/{name}"`)
+}
+
+func TestTableMetricsFollowArrivals(t *testing.T) {
+	// Not parallel, the gauges are shared by every test
+	db, router := newTestRouter(t)
+	load(t, db, `
+tables: [{number: 1, seats: 4}]
+reservations: [{name: bob, table: 1, accompanying_guests: 1}]
+`)
+	service.RefreshMetrics(db)
+
+	send(t, router, "PUT", "/v2/arrivals/bob", `{ "accompanying_guests": 1 }`, http.StatusCreated)
+
+	out := scrape(t)
+	assert.Contains(t, out, `guestlist_table_reserved_seats{table="1"} 2`)
+	assert.Contains(t, out, `guestlist_table_arrived_guests{table="1"} 2`)
+	assert.Contains(t, out, `guestlist_table_empty_seats{table="1"} 2`)
+
+	send(t, router, "DELETE", "/v2/arrivals/bob", "", http.StatusNoContent)
+	out = scrape(t)
+	assert.Contains(t, out, `guestlist_table_arrived_guests{table="1"} 0`)
+	assert.Contains(t, out, `guestlist_table_empty_seats{table="1"} 4`)
+}
+
+func scrape(t *testing.T) string {
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	HandleMetrics(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	return rr.Body.String()
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// sortColumns maps the sort options for reservations to the column they are ordered by
+var sortColumns = map[string]string{
+	"created":      "reservations.id",
+	"name":         "reservations.guest",
+	"table":        "tables.number",
+	"arrival_time": "reservations.arrival_time",
+}
+
+// reservationQuery is how a list of reservations should be filtered, sorted and paged, it is read from the query
+// string of list requests
+type reservationQuery struct {
+	TableNumber   *int
+	Arrived       *bool
+	ArrivedAfter  *time.Time
+	ArrivedBefore *time.Time
+	NamePrefix    string
+	// Sort is one of the sortColumns keys, prefixed with - to sort descending
+	Sort   string
+	Limit  int
+	Cursor *cursor
+}
+
+// cursor marks the last reservation of a page, the next page starts after it
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"i"`
+}
+
+// encodeCursor turns a cursor into an opaque string for clients to send back
+func encodeCursor(c cursor) string {
+	out, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(out)
+}
+
+// decodeCursor reads a cursor created by encodeCursor
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(raw, &c)
+	return c, err
+}
+
+// parseReservationQuery reads the filters, sort and paging options from a list request. pageSize is the limit when the
+// request doesn't give one, 0 lists every reservation unless the request sends a limit or cursor, which the v1 lists
+// use so clients written before paging still get everything
+//
+//	table=int, arrived=bool, arrived_after=RFC3339, arrived_before=RFC3339, name_prefix=string,
+//	sort=created|name|table|arrival_time (prefix - for descending), limit=int, cursor=string
+func parseReservationQuery(r *http.Request, pageSize int) (reservationQuery, error) {
+	values := r.URL.Query()
+	q := reservationQuery{
+		NamePrefix: values.Get("name_prefix"),
+		Sort:       values.Get("sort"),
+		Limit:      pageSize,
+	}
+
+	if v := values.Get("table"); v != "" {
+		table, err := strconv.Atoi(v)
+		if err != nil {
+			return q, apierror.Validation("invalid table %q", v)
+		}
+		q.TableNumber = &table
+	}
+
+	if v := values.Get("arrived"); v != "" {
+		arrived, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, apierror.Validation("invalid arrived %q, must be true or false", v)
+		}
+		q.Arrived = &arrived
+	}
+
+	for name, dest := range map[string]**time.Time{"arrived_after": &q.ArrivedAfter, "arrived_before": &q.ArrivedBefore} {
+		if v := values.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, apierror.Validation("invalid %s %q, must be an RFC 3339 time", name, v)
+			}
+			*dest = &t
+		}
+	}
+
+	if q.Sort == "" {
+		q.Sort = "created"
+	}
+	if _, ok := sortColumns[strings.TrimPrefix(q.Sort, "-")]; !ok {
+		return q, apierror.Validation("invalid sort %q, must be one of created, name, table or arrival_time", q.Sort)
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return q, apierror.Validation("invalid limit %q, must be between 1 and %d", v, maxPageSize)
+		}
+		q.Limit = limit
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return q, apierror.Validation("invalid cursor")
+		}
+		if c.Sort != q.Sort {
+			return q, apierror.Validation("cursor was created with a different sort")
+		}
+		q.Cursor = &c
+		if q.Limit == 0 {
+			q.Limit = defaultPageSize
+		}
+	}
+
+	return q, nil
+}
+
+// apply adds the filters, ordering, cursor and limit to a query on reservations joined with their tables. One more
+// row than the limit is requested so callers can tell if there is another page, a limit of 0 gets every row
+func (q reservationQuery) apply(db *gorm.DB) (*gorm.DB, error) {
+	query := db.Joins("JOIN tables ON tables.id = reservations.table_id")
+
+	if q.TableNumber != nil {
+		query = query.Where("tables.number = ?", *q.TableNumber)
+	}
+	if q.Arrived != nil {
+		if *q.Arrived {
+			query = query.Where("reservations.arrival_time IS NOT NULL")
+		} else {
+			query = query.Where("reservations.arrival_time IS NULL")
+		}
+	}
+	if q.ArrivedAfter != nil {
+		query = query.Where("reservations.arrival_time >= ?", *q.ArrivedAfter)
+	}
+	if q.ArrivedBefore != nil {
+		query = query.Where("reservations.arrival_time < ?", *q.ArrivedBefore)
+	}
+	if q.NamePrefix != "" {
+		escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(q.NamePrefix)
+		query = query.Where("reservations.guest LIKE ? ESCAPE '!'", escaped+"%")
+	}
+
+	field := strings.TrimPrefix(q.Sort, "-")
+	column := sortColumns[field]
+	descending := strings.HasPrefix(q.Sort, "-")
+
+	// Guests that haven't arrived have no arrival time to page by, so only arrived guests can be sorted that way
+	if field == "arrival_time" {
+		if q.Arrived != nil && !*q.Arrived {
+			return nil, apierror.Validation("sorting by arrival_time requires arrived guests")
+		}
+		query = query.Where("reservations.arrival_time IS NOT NULL")
+	}
+
+	if q.Cursor != nil {
+		value, err := q.cursorValue(field)
+		if err != nil {
+			return nil, apierror.Validation("invalid cursor")
+		}
+		op := ">"
+		if descending {
+			op = "<"
+		}
+		if field == "created" {
+			query = query.Where(fmt.Sprintf("reservations.id %s ?", op), q.Cursor.ID)
+		} else {
+			query = query.Where(fmt.Sprintf("((%s %s ?) OR (%s = ? AND reservations.id %s ?))", column, op, column, op),
+				value, value, q.Cursor.ID)
+		}
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+	if field != "created" {
+		query = query.Order(fmt.Sprintf("%s %s", column, direction))
+	}
+	query = query.Order(fmt.Sprintf("reservations.id %s", direction))
+
+	if q.Limit == 0 {
+		return query, nil
+	}
+	return query.Limit(q.Limit + 1), nil
+}
+
+// cursorValue converts the cursor's value back into the type of the sort column
+func (q reservationQuery) cursorValue(field string) (interface{}, error) {
+	switch field {
+	case "table":
+		return strconv.Atoi(q.Cursor.Value)
+	case "arrival_time":
+		return time.Parse(time.RFC3339Nano, q.Cursor.Value)
+	default:
+		return q.Cursor.Value, nil
+	}
+}
+
+// listReservations gets a page of reservations, with their tables, matching the query. If there are more
+// reservations after this page a cursor for the next page is returned
+func listReservations(db *gorm.DB, q reservationQuery) ([]model.Reservation, string, error) {
+	query, err := q.apply(db.Model(&model.Reservation{}).Select("reservations.*").Preload("Table"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var reservations []model.Reservation
+	if err := query.Find(&reservations).Error; err != nil {
+		return nil, "", apierror.Internal(err, "failed to load reservations")
+	}
+	if q.Limit == 0 || len(reservations) <= q.Limit {
+		return reservations, "", nil
+	}
+
+	reservations = reservations[:q.Limit]
+	last := reservations[len(reservations)-1]
+	next := cursor{Sort: q.Sort, ID: last.ID}
+	switch strings.TrimPrefix(q.Sort, "-") {
+	case "name":
+		next.Value = last.Guest
+	case "table":
+		next.Value = strconv.Itoa(last.Table.Number)
+	case "arrival_time":
+		next.Value = last.ArrivalTime.Format(time.RFC3339Nano)
+	}
+	return reservations, encodeCursor(next), nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestParseReservationQuery(t *testing.T) {
+	nameCursor := encodeCursor(cursor{Sort: "name", Value: "bob", ID: 3})
+
+	cases := []struct {
+		name        string
+		query       string
+		expectedErr string
+	}{
+		{"defaults", "", ""},
+		{"everything", "table=1&arrived=true&arrived_after=2020-12-24T18:00:00Z&arrived_before=2020-12-25T02:00:00Z&name_prefix=bo&sort=-arrival_time&limit=10", ""},
+		{"cursor", "sort=name&cursor=" + nameCursor, ""},
+		{"badTable", "table=one", `invalid table "one"`},
+		{"badArrived", "arrived=maybe", `invalid arrived "maybe", must be true or false`},
+		{"badTime", "arrived_after=yesterday", `invalid arrived_after "yesterday", must be an RFC 3339 time`},
+		{"badSort", "sort=seats", `invalid sort "seats", must be one of created, name, table or arrival_time`},
+		{"badLimit", "limit=0", `invalid limit "0", must be between 1 and 1000`},
+		{"hugeLimit", "limit=5000", `invalid limit "5000", must be between 1 and 1000`},
+		{"badCursor", "cursor=foo", "invalid cursor"},
+		{"cursorOtherSort", "sort=-name&cursor=" + nameCursor, "cursor was created with a different sort"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/guest_list?"+c.query, nil)
+			require.NoError(t, err)
+
+			q, err := parseReservationQuery(req, defaultPageSize)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, q.Sort)
+			assert.NotZero(t, q.Limit)
+		})
+	}
+}
+
+func TestParseReservationQuery_Unpaged(t *testing.T) {
+	cases := []struct {
+		name          string
+		query         string
+		expectedLimit int
+	}{
+		{"everything", "", 0},
+		{"limit", "limit=10", 10},
+		{"cursor", "cursor=" + encodeCursor(cursor{Sort: "created", ID: 3}), defaultPageSize},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/guest_list?"+c.query, nil)
+			require.NoError(t, err)
+
+			q, err := parseReservationQuery(req, 0)
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedLimit, q.Limit)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c := cursor{Sort: "-table", Value: "5", ID: 12}
+
+	decoded, err := decodeCursor(encodeCursor(c))
+	require.NoError(t, err)
+	assert.Equal(t, c, decoded)
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/requestid"
+	"net/http"
+)
+
+// RequestID gives every request an ID, sent back in the X-Request-ID header and kept in the request's context. An ID
+// sent by the client is used as long as it is short and printable, otherwise a new one is generated
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+	}))
+
+	cases := []struct {
+		name     string
+		sent     string
+		expected string
+	}{
+		{"generated", "", ""},
+		{"fromClient", "checkin-42", "checkin-42"},
+		{"tooLong", strings.Repeat("a", requestid.MaxLength+1), ""},
+		{"notPrintable", "bad id\n", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/tables", nil)
+			require.NoError(t, err)
+			if c.sent != "" {
+				req.Header.Set(requestid.Header, c.sent)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rr.Header().Get(requestid.Header))
+			if c.expected != "" {
+				assert.Equal(t, c.expected, seen)
+			} else {
+				assert.NotEqual(t, c.sent, seen)
+			}
+		})
+	}
+}
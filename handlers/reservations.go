@@ -2,12 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/apierror"
 	"github.com/ctompkinson/guest-list/model"
-	"gorm.io/gorm"
+	"github.com/ctompkinson/guest-list/service"
 	"net/http"
 )
 
@@ -18,18 +16,22 @@ type createGuestListRequest struct {
 type createGuestListResponse struct {
 	Name string `json:"name"`
 }
+type getReservationsResponse struct {
+	Guests     []model.FormattedReservation `json:"guests"`
+	NextCursor string                       `json:"next_cursor,omitempty"`
+}
 
 // HandleCreateReservation creates a new reservation given a primary guest,
 // the amount of guests and a valid table number
 func HandleCreateReservation(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
+	db := requestDB(r)
 	// POST /guest_list/{name}
 	// { "table": int, "accompanying_guests": int }
 
 	// Get guest name from URL params
 	guestName := mux.Vars(r)["name"]
 	if guestName == "" {
-		ErrorResponse(w, http.StatusBadRequest, "unable to retrieve guest name from URL")
+		ErrorResponse(w, r, apierror.BadRequest("unable to retrieve guest name from URL"))
 		return
 	}
 
@@ -37,54 +39,20 @@ func HandleCreateReservation(w http.ResponseWriter, r *http.Request) {
 	var reqBody createGuestListRequest
 	err := json.NewDecoder(r.Body).Decode(&reqBody)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("unable to parse body: %v", err))
-		return
-	}
-
-	// Find the table
-	var table model.Table
-	if err := db.Where("number = ?", reqBody.TableNumber).First(&table).Error; err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to find table: %v", err))
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
 		return
 	}
 
-	// Check if that person has any reservations already under their name
-	var existingGuestReservations []model.Reservation
-	result := db.Where("guest = ?", guestName).First(&existingGuestReservations)
-	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to query for existing guest reservations: %v", result.Error))
-		return
-	}
-	if result.Error == nil {
-		ErrorResponse(w, http.StatusInternalServerError, "the guest already has a reservation")
-		return
-	}
-
-	// Check for all our guests, plus the main guest
-	enoughSeats, err := areEnoughSeatsAvailable(table, reqBody.AccompanyingGuests+1)
+	reservation, err := service.CreateReservation(db, service.NewReservation{Guest: guestName, TableNumber: reqBody.TableNumber, AccompanyingGuests: reqBody.AccompanyingGuests})
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to check available seats: %v", err))
-		return
-	}
-	if !enoughSeats {
-		ErrorResponse(w, http.StatusInternalServerError, "not enough seats available on selected table")
-		return
-	}
-
-	// Now we can finally create the reservation
-	reservation := model.Reservation{
-		Guest:              guestName,
-		AccompanyingGuests: reqBody.AccompanyingGuests,
-		Table:              table,
-	}
-	if err := db.Create(&reservation).Error; err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to create reservations: %v", err))
+		ErrorResponse(w, r, err)
 		return
 	}
+	setETag(w, reservation.Version)
 
 	out, err := json.Marshal(createGuestListResponse{Name: guestName})
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to marshal response: %v", err))
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal response"))
 		return
 	}
 	http.StatusText(http.StatusOK)
@@ -93,29 +61,16 @@ func HandleCreateReservation(w http.ResponseWriter, r *http.Request) {
 
 // HandleDeleteReservation deletes a reservation given the primary guests name
 func HandleDeleteReservation(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
-
-	guestName := mux.Vars(r)["name"]
-	if guestName == "" {
-		http.Error(w, "unable to retrieve guest name from URL", http.StatusBadRequest)
-		return
-	}
+	db := requestDB(r)
 
 	// Check if reservation exists
-	var reservation model.Reservation
-	if err := db.Where("guest = ?", guestName).First(&reservation).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "guest does not have a reservation", http.StatusBadRequest)
-			return
-		}
-
-		http.Error(w, fmt.Sprintf("failed to lookup reservation: %v", err), http.StatusInternalServerError)
+	reservation, ok := findReservation(w, r)
+	if !ok {
 		return
 	}
 
-	// Delete table, use unscoped to ensure a hard delete and not soft
-	if err := db.Unscoped().Delete(&reservation).Error; err != nil {
-		http.Error(w, fmt.Sprintf("failed to delete reservation: %v", err), http.StatusInternalServerError)
+	if err := service.DeleteReservation(db, reservation); err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
 
@@ -123,13 +78,18 @@ func HandleDeleteReservation(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte(`{"message":"deleted"}`))
 }
 
-// HandleGetReservations gets all the existing reservations
+// HandleGetReservations gets the existing reservations, only a page of them when a limit or cursor is given, see
+// parseReservationQuery for the filters
 func HandleGetReservations(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
+	q, err := parseReservationQuery(r, 0)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
 
-	var reservations []model.Reservation
-	if err := db.Preload("Table").Find(&reservations).Error; err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load reservations: %v", err))
+	reservations, next, err := listReservations(requestDB(r), q)
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
 
@@ -138,14 +98,30 @@ func HandleGetReservations(w http.ResponseWriter, r *http.Request) {
 		formattedReservations = append(formattedReservations, res.FormatAsReservation())
 	}
 
-	out, err := json.Marshal(map[string][]model.FormattedReservation{
-		"guests": formattedReservations,
+	out, err := json.Marshal(getReservationsResponse{
+		Guests:     formattedReservations,
+		NextCursor: next,
 	})
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to marshal reservations: %v", err))
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal reservations"))
 		return
 	}
 
 	http.StatusText(http.StatusOK)
 	_, _ = w.Write(out)
 }
+
+// findReservation loads the reservation, and its table, for the guest named in the URL and sets its ETag, writing an
+// error response if it can't or the request's If-Match doesn't match it
+func findReservation(w http.ResponseWriter, r *http.Request) (model.Reservation, bool) {
+	reservation, err := service.GetReservation(requestDB(r), mux.Vars(r)["name"])
+	if err == nil {
+		err = checkIfMatch(r, reservation.Version, "reservation")
+	}
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return reservation, false
+	}
+	setETag(w, reservation.Version)
+	return reservation, true
+}
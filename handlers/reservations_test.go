@@ -2,9 +2,7 @@ package handlers
 
 import (
 	"bytes"
-	"github.com/gorilla/mux"
-	"github.com/ctompkinson/guest-list/database"
-	"github.com/ctompkinson/guest-list/model"
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -14,8 +12,7 @@ import (
 )
 
 func TestHandleCreateReservation(t *testing.T) {
-	database.Init()
-	db := database.Get()
+	t.Parallel()
 
 	cases := []struct {
 		name             string
@@ -23,7 +20,7 @@ func TestHandleCreateReservation(t *testing.T) {
 		body             string
 		expectedStatus   int
 		expectedResponse string
-		createTable      *model.Table
+		fixture          string
 	}{
 		{
 			"good",
@@ -31,48 +28,55 @@ func TestHandleCreateReservation(t *testing.T) {
 			`{ "table": 1, "accompanying_guests": 5 }`,
 			http.StatusOK,
 			`{"name":"bob"}`,
-			&model.Table{Number: 1, Seats: 6},
+			`tables: [{number: 1, seats: 6}]`,
 		},
 		{
 			"noData",
 			"/guest_list/bob",
 			``,
 			http.StatusBadRequest,
-			`{"message":"unable to parse body: EOF"}`,
-			nil,
+			`{"type":"urn:guest-list:problem:bad_request","title":"Bad Request","status":400,"detail":"unable to parse body: EOF","instance":"/guest_list/bob","code":"bad_request"}`,
+			"",
 		},
 		{
 			"noTable",
 			"/guest_list/bob",
 			`{ "table": 1, "accompanying_guests": 5 }`,
-			http.StatusInternalServerError,
-			`{"message":"failed to find table: record not found"}`,
-			nil,
+			http.StatusNotFound,
+			`{"type":"urn:guest-list:problem:not_found","title":"Not Found","status":404,"detail":"table 1 does not exist","instance":"/guest_list/bob","code":"not_found"}`,
+			"",
 		},
 		{
 			"noSeats",
 			"/guest_list/bob",
 			`{ "table": 1, "accompanying_guests": 5 }`,
-			http.StatusInternalServerError,
-			`{"message":"not enough seats available on selected table"}`,
-			&model.Table{Number: 1, Seats: 5},
+			http.StatusConflict,
+			`{"type":"urn:guest-list:problem:capacity_exceeded","title":"Conflict","status":409,"detail":"not enough seats available on selected table","instance":"/guest_list/bob","code":"capacity_exceeded"}`,
+			`tables: [{number: 1, seats: 5}]`,
+		},
+		{
+			"negativeGuests",
+			"/guest_list/bob",
+			`{ "table": 1, "accompanying_guests": -1 }`,
+			http.StatusUnprocessableEntity,
+			`{"type":"urn:guest-list:problem:validation","title":"Unprocessable Entity","status":422,"detail":"accompanying guests can't be negative","instance":"/guest_list/bob","code":"validation"}`,
+			`tables: [{number: 1, seats: 5}]`,
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
+			t.Parallel()
+			db, router := newTestRouter(t)
 
-			if c.createTable != nil {
-				db.Create(c.createTable)
+			if c.fixture != "" {
+				load(t, db, c.fixture)
 			}
 
 			req, err := http.NewRequest("POST", c.url, bytes.NewBuffer([]byte(c.body)))
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/guest_list/{name}", HandleCreateReservation)
 			router.ServeHTTP(rr, req)
 
 			assert.Equal(t, c.expectedStatus, rr.Code)
@@ -83,72 +87,68 @@ func TestHandleCreateReservation(t *testing.T) {
 }
 
 func TestHandleCreateReservation_Duplicate(t *testing.T) {
-	database.Init()
-	database.ClearAndCreate()
-	db := database.Get()
+	t.Parallel()
+	db, router := newTestRouter(t)
 
-	db.Create(&model.Table{Number: 1, Seats: 10})
+	load(t, db, `tables: [{number: 1, seats: 10}]`)
 
 	req, err := http.NewRequest("POST", "/guest_list/bob",
 		bytes.NewBuffer([]byte(`{ "table": 1, "accompanying_guests": 5 }`)))
 	require.NoError(t, err)
 
-	router := mux.NewRouter()
-	router.HandleFunc("/guest_list/{name}", HandleCreateReservation)
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusOK, rr.Code)
 
+	req, err = http.NewRequest("POST", "/guest_list/bob",
+		bytes.NewBuffer([]byte(`{ "table": 1, "accompanying_guests": 5 }`)))
+	require.NoError(t, err)
 	rr2 := httptest.NewRecorder()
 	router.ServeHTTP(rr2, req)
-	assert.Equal(t, http.StatusBadRequest, rr2.Code)
+	assert.Equal(t, http.StatusConflict, rr2.Code)
+	assert.Equal(t, "application/problem+json", rr2.Header().Get("Content-Type"))
+	assert.Equal(t, `{"type":"urn:guest-list:problem:conflict","title":"Conflict","status":409,"detail":"the guest already has a reservation","instance":"/guest_list/bob","code":"conflict"}`, strings.TrimSpace(rr2.Body.String()))
 }
 
 func TestHandleDeleteReservation(t *testing.T) {
-	database.Init()
-	db := database.Get()
+	t.Parallel()
 
 	cases := []struct {
-		name              string
-		url               string
-		expectedStatus    int
-		createReservation *model.Reservation
-		createTable       *model.Table
+		name           string
+		url            string
+		expectedStatus int
+		fixture        string
 	}{
 		{
 			"good",
 			"/guest_list/bob",
 			http.StatusOK,
-			&model.Reservation{Guest: "bob", AccompanyingGuests: 1},
-			&model.Table{Number: 1, Seats: 5},
+			`
+tables: [{number: 1, seats: 5}]
+reservations: [{name: bob, table: 1, accompanying_guests: 1}]
+`,
 		},
 		{
 			"noReservation",
 			"/guest_list/bob",
-			http.StatusBadRequest,
-			nil,
-			nil,
+			http.StatusNotFound,
+			"",
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
+			t.Parallel()
+			db, router := newTestRouter(t)
 
-			if c.createTable != nil {
-				db.Create(c.createTable)
-			}
-			if c.createReservation != nil {
-				c.createReservation.Table = *c.createTable
-				db.Create(c.createReservation)
+			if c.fixture != "" {
+				load(t, db, c.fixture)
 			}
 
 			req, err := http.NewRequest("DELETE", c.url, nil)
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/guest_list/{name}", HandleDeleteReservation)
 			router.ServeHTTP(rr, req)
 
 			assert.Equal(t, c.expectedStatus, rr.Code)
@@ -158,58 +158,49 @@ func TestHandleDeleteReservation(t *testing.T) {
 }
 
 func TestHandleGetReservations(t *testing.T) {
-	database.Init()
-	db := database.Get()
+	t.Parallel()
 
 	cases := []struct {
-		name               string
-		url                string
-		expectedStatus     int
-		expectedResponse   string
-		createReservations []*model.Reservation
-		createTable        *model.Table
+		name             string
+		url              string
+		expectedStatus   int
+		expectedResponse string
+		fixture          string
 	}{
 		{
 			"good",
 			"/guest_list",
 			http.StatusOK,
 			`{"guests":[{"name":"bob","table":1,"accompanying_guests":1},{"name":"taylor","table":1,"accompanying_guests":2}]}`,
-			[]*model.Reservation{
-				{Guest: "bob", AccompanyingGuests: 1},
-				{Guest: "taylor", AccompanyingGuests: 2},
-			},
-			&model.Table{Number: 1, Seats: 5},
+			`
+tables: [{number: 1, seats: 5}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 1}
+  - {name: taylor, table: 1, accompanying_guests: 2}
+`,
 		},
 		{
 			"noReservation",
 			"/guest_list",
 			http.StatusOK,
 			`{"guests":[]}`,
-			nil,
-			nil,
+			"",
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
+			t.Parallel()
+			db, router := newTestRouter(t)
 
-			if c.createTable != nil {
-				db.Create(c.createTable)
-			}
-			if c.createReservations != nil {
-				for _, res := range c.createReservations {
-					res.Table = *c.createTable
-					db.Create(res)
-				}
+			if c.fixture != "" {
+				load(t, db, c.fixture)
 			}
 
 			req, err := http.NewRequest("GET", c.url, nil)
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/guest_list", HandleGetReservations)
 			router.ServeHTTP(rr, req)
 
 			assert.Equal(t, c.expectedStatus, rr.Code)
@@ -218,3 +209,54 @@ func TestHandleGetReservations(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleGetReservations_Paging(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+
+	load(t, db, `
+tables: [{number: 1, seats: 10}, {number: 2, seats: 10}]
+reservations:
+  - {name: dave, table: 1}
+  - {name: bob, table: 1}
+  - {name: alice, table: 1}
+  - {name: carol, table: 1}
+  - {name: bobby, table: 1}
+  - {name: erin, table: 2}
+`)
+
+
+	get := func(url string) getReservationsResponse {
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var res getReservationsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		return res
+	}
+	names := func(res getReservationsResponse) []string {
+		out := []string{}
+		for _, g := range res.Guests {
+			out = append(out, g.Guest)
+		}
+		return out
+	}
+
+	page := get("/guest_list?sort=name&limit=2&table=1")
+	assert.Equal(t, []string{"alice", "bob"}, names(page))
+	require.NotEmpty(t, page.NextCursor)
+
+	page = get("/guest_list?sort=name&limit=2&table=1&cursor=" + page.NextCursor)
+	assert.Equal(t, []string{"bobby", "carol"}, names(page))
+
+	page = get("/guest_list?sort=name&limit=2&table=1&cursor=" + page.NextCursor)
+	assert.Equal(t, []string{"dave"}, names(page))
+	assert.Empty(t, page.NextCursor)
+
+	assert.Equal(t, []string{"erin", "dave", "carol"}, names(get("/guest_list?sort=-name&limit=3")))
+	assert.Equal(t, []string{"bob", "bobby"}, names(get("/guest_list?name_prefix=bob&sort=name")))
+	assert.Equal(t, []string{"erin"}, names(get("/guest_list?sort=-table&limit=1")))
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/auth"
+	"github.com/gorilla/mux"
+)
+
+// NewRouter creates a router with every API route, any route added here must also be described in
+// openapi/openapi.yaml. Every route needs a permission, see auth.Roles for which roles have them
+func NewRouter() *mux.Router {
+	router := mux.NewRouter()
+	// Idempotency is added to each route by Require, after the route's permission is checked
+	router.Use(RequestID, AccessLog, Tracing, Metrics, Authenticate, RequireDatabase)
+
+	router.HandleFunc("/table/{tableNumber}", Require(auth.Plan, HandleCreateTable)).Methods("POST")
+	router.HandleFunc("/table/{tableNumber}", Require(auth.Plan, HandleUpdateTable)).Methods("PUT")
+	router.HandleFunc("/table/{tableNumber}", Require(auth.Plan, HandleDeleteTable)).Methods("DELETE")
+	router.HandleFunc("/table/{tableNumber}", Require(auth.Read, HandleGetTable)).Methods("GET")
+	router.HandleFunc("/tables", Require(auth.Read, HandleListTables)).Methods("GET")
+
+	router.HandleFunc("/guest_list/{name}", Require(auth.Plan, HandleCreateReservation)).Methods("POST")
+	router.HandleFunc("/guest_list/{name}", Require(auth.Plan, HandleDeleteReservation)).Methods("DELETE")
+	router.HandleFunc("/guest_list", Require(auth.Read, HandleGetReservations)).Methods("GET")
+
+	router.HandleFunc("/guests", Require(auth.Read, HandleListGuests)).Methods("GET")
+	router.HandleFunc("/guest/{name}", Require(auth.CheckIn, HandleGuestArrival)).Methods("PUT")
+	// We reuse delete reservation because its effectively the same thing, so it needs the same permission
+	router.HandleFunc("/guest/{name}", Require(auth.Plan, HandleDeleteReservation)).Methods("DELETE")
+
+	router.HandleFunc("/seats_empty", Require(auth.Read, HandleGetEmptySeats)).Methods("GET")
+	router.HandleFunc("/invitation/{name}", Require(auth.Read, HandleCreateInvitation)).Methods("GET")
+
+	// Version 2 of the API, the routes above are kept for existing clients
+	v2 := router.PathPrefix("/v2").Subrouter()
+	v2.HandleFunc("/tables", Require(auth.Read, HandleV2ListTables)).Methods("GET")
+	v2.HandleFunc("/tables", Require(auth.Plan, HandleV2CreateTable)).Methods("POST")
+	v2.HandleFunc("/tables/bulk", Require(auth.Plan, HandleV2BulkTables)).Methods("POST")
+	v2.HandleFunc("/tables/{tableNumber}", Require(auth.Read, HandleV2GetTable)).Methods("GET")
+	v2.HandleFunc("/tables/{tableNumber}", Require(auth.Plan, HandleV2UpdateTable)).Methods("PATCH")
+	v2.HandleFunc("/tables/{tableNumber}", Require(auth.Plan, HandleV2DeleteTable)).Methods("DELETE")
+
+	v2.HandleFunc("/reservations", Require(auth.Read, HandleV2ListReservations)).Methods("GET")
+	v2.HandleFunc("/reservations", Require(auth.Plan, HandleV2CreateReservation)).Methods("POST")
+	v2.HandleFunc("/reservations/{name}", Require(auth.Read, HandleV2GetReservation)).Methods("GET")
+	v2.HandleFunc("/reservations/{name}", Require(auth.Plan, HandleV2DeleteReservation)).Methods("DELETE")
+
+	v2.HandleFunc("/arrivals", Require(auth.Read, HandleV2ListArrivals)).Methods("GET")
+	v2.HandleFunc("/arrivals/{name}", Require(auth.Read, HandleV2GetArrival)).Methods("GET")
+	v2.HandleFunc("/arrivals/{name}", Require(auth.CheckIn, HandleV2PutArrival)).Methods("PUT")
+	v2.HandleFunc("/arrivals/{name}", Require(auth.CheckIn, HandleV2DeleteArrival)).Methods("DELETE")
+
+	v2.HandleFunc("/seats", Require(auth.Read, HandleV2GetSeats)).Methods("GET")
+
+	v2.HandleFunc("/deleted/tables", Require(auth.Read, HandleV2ListDeletedTables)).Methods("GET")
+	v2.HandleFunc("/deleted/tables/{tableNumber}/restore", Require(auth.Plan, HandleV2RestoreTable)).Methods("POST")
+	v2.HandleFunc("/deleted/reservations", Require(auth.Read, HandleV2ListDeletedReservations)).Methods("GET")
+	v2.HandleFunc("/deleted/reservations/{name}/restore", Require(auth.Plan, HandleV2RestoreReservation)).Methods("POST")
+
+	router.HandleFunc("/import", Require(auth.Plan, HandleImport)).Methods("POST")
+	router.HandleFunc("/export/{sheet}", Require(auth.Read, HandleExport)).Methods("GET")
+	router.HandleFunc("/backup", Require(auth.Admin, HandleBackup)).Methods("GET")
+	router.HandleFunc("/restore", Require(auth.Admin, HandleRestore)).Methods("POST")
+
+	router.HandleFunc("/webhooks", Require(auth.Admin, HandleCreateWebhook)).Methods("POST")
+	router.HandleFunc("/webhooks", Require(auth.Admin, HandleListWebhooks)).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", Require(auth.Admin, HandleDeleteWebhook)).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/deliveries", Require(auth.Admin, HandleListWebhookDeliveries)).Methods("GET")
+
+	router.HandleFunc("/api_keys", Require(auth.Admin, HandleCreateAPIKey)).Methods("POST")
+	router.HandleFunc("/api_keys", Require(auth.Admin, HandleListAPIKeys)).Methods("GET")
+	router.HandleFunc("/api_keys/{id}", Require(auth.Admin, HandleDeleteAPIKey)).Methods("DELETE")
+
+	router.HandleFunc("/audit", Require(auth.Admin, HandleListAuditEntries)).Methods("GET")
+
+	// Unlike the health checks metrics need an API key, Prometheus is given a read only one as its bearer token
+	router.HandleFunc("/metrics", Require(auth.Read, HandleMetrics)).Methods("GET")
+
+	// Health checks are public so the orchestrator can use them without credentials
+	router.HandleFunc("/health/live", HandleLiveness).Methods("GET")
+	router.HandleFunc("/health/ready", HandleReadiness).Methods("GET")
+
+	return router
+}
@@ -2,12 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/apierror"
 	"github.com/ctompkinson/guest-list/model"
-	"gorm.io/gorm"
+	"github.com/ctompkinson/guest-list/service"
 	"net/http"
 	"strconv"
 )
@@ -16,82 +14,83 @@ type createTableRequest struct {
 	Seats int `json:"seats"`
 }
 
+type updateTableRequest struct {
+	Number *int `json:"number"`
+	Seats  *int `json:"seats"`
+}
+
 // HandleCreateTable creates a new table which can be used
 // It must have a unique table number
 func HandleCreateTable(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
+	db := requestDB(r)
 	tableNumber := mux.Vars(r)["tableNumber"]
 	t, err := strconv.ParseInt(tableNumber, 10, 0)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to parse table number: %v", err), http.StatusBadRequest)
-		return
-	}
-
-	// Check if there is any tables with that number
-	var table model.Table
-	result := db.Where("number = ?", tableNumber).First(&table)
-	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		http.Error(w, fmt.Sprintf("failed to check if table exists: %v", result.Error), http.StatusInternalServerError)
-		return
-	}
-	if result.RowsAffected != 0 {
-		http.Error(w, "a table exists with that number already", http.StatusInternalServerError)
+		ErrorResponse(w, r, apierror.BadRequest("failed to parse table number: %v", err))
 		return
 	}
 
 	// Get table seats
 	var body createTableRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, fmt.Sprintf("failed to parse body: %v", err), http.StatusBadRequest)
+		ErrorResponse(w, r, apierror.BadRequest("failed to parse body: %v", err))
 		return
 	}
 
-	// Create Table
-	table = model.Table{
-		Number: int(t),
-		Seats:  body.Seats,
-	}
-	if err := db.Create(&table).Error; err != nil {
-		http.Error(w, fmt.Sprintf("failed to create table: %v", err), http.StatusInternalServerError)
+	table, err := service.CreateTable(db, service.NewTable{Number: int(t), Seats: body.Seats})
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
+	setETag(w, table.Version)
 
 	http.StatusText(http.StatusOK)
 	_, _ = w.Write([]byte(`{ "status": "created" }`))
 }
 
-// HandleDeleteTable deletes a table given its table number
-func HandleDeleteTable(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
+// HandleUpdateTable changes a table's number or seats, fields that are left out aren't changed. A table can't be
+// given fewer seats than are reserved on it unless ?shrink=force (the table is left overbooked) or ?shrink=rebalance
+// (guests that haven't arrived are moved to other tables) is given
+func HandleUpdateTable(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+	// PUT /table/{tableNumber}?shrink=force|rebalance
+	// { "number": int, "seats": int }
 
-	// Get the table number from the parameters and check it
-	tableNumber := mux.Vars(r)["tableNumber"]
-	if tableNumber == "" {
-		http.Error(w, fmt.Sprintf("failed to give valid tableNumber: %v", tableNumber), http.StatusBadRequest)
+	table, ok := findTable(w, r)
+	if !ok {
 		return
 	}
 
-	// Grab the table in question
-	var table model.Table
-	if err := db.Where("number = ?", tableNumber).First(&table).Error; err != nil {
-		http.Error(w, fmt.Sprintf("unable to find table: %v", tableNumber), http.StatusInternalServerError)
+	var body updateTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("failed to parse body: %v", err))
 		return
 	}
 
-	// Check if there is any reservations and stop table deletion
-	var reservation model.Reservation
-	result := db.Where("table_id = ?", table.ID).First(&reservation)
-	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		http.Error(w, fmt.Sprintf("failed to query reservations: %v", result.Error), http.StatusInternalServerError)
+	update := service.TableUpdate{Number: body.Number, Seats: body.Seats, Shrink: service.ShrinkMode(r.URL.Query().Get("shrink"))}
+	table, _, err := service.UpdateTable(db, table, update)
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
-	if reservation.ID != 0 { // Same as a nil check
-		http.Error(w, "cannot delete a table with a reservation", http.StatusInternalServerError)
+	setETag(w, table.Version)
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write([]byte(`{ "status": "updated" }`))
+}
+
+// HandleDeleteTable deletes a table given its table number
+func HandleDeleteTable(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	// Get the table number from the parameters and check it
+	table, ok := findTable(w, r)
+	if !ok {
 		return
 	}
 
-	if err := db.Where("id = ?", table.ID).Unscoped().Delete(&model.Table{}).Error; err != nil {
-		http.Error(w, fmt.Sprintf("failed to delete table: %v", err), http.StatusInternalServerError)
+	if err := service.DeleteTable(db, table); err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
 
@@ -101,27 +100,57 @@ func HandleDeleteTable(w http.ResponseWriter, r *http.Request) {
 
 // HandleGetTable gets the information about a table given its table number
 func HandleGetTable(w http.ResponseWriter, r *http.Request) {
-	db := database.Get()
+	t, ok := findTable(w, r)
+	if !ok {
+		return
+	}
 
-	tableNumber := mux.Vars(r)["tableNumber"]
-	if tableNumber == "" {
-		http.Error(w, fmt.Sprintf("failed to give valid tableNumber: %v", tableNumber), http.StatusBadRequest)
+	out, err := json.Marshal(t)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal response"))
 		return
 	}
 
-	var t model.Table
-	result := db.Where("number = ?", tableNumber).First(&t)
-	if result.Error != nil {
-		http.Error(w, fmt.Sprintf("failed to get table: %v", result.Error), http.StatusInternalServerError)
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// HandleListTables lists every table ordered by number, with how many of its seats are reserved, taken by guests
+// that have arrived and still free
+func HandleListTables(w http.ResponseWriter, r *http.Request) {
+	summaries, err := service.ListTables(requestDB(r))
+	if err != nil {
+		ErrorResponse(w, r, err)
 		return
 	}
 
-	out, err := json.Marshal(t)
+	out, err := json.Marshal(map[string][]model.TableSummary{"tables": summaries})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to marshal response: %v", err), http.StatusInternalServerError)
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal tables"))
 		return
 	}
 
 	http.StatusText(http.StatusOK)
 	_, _ = w.Write(out)
 }
+
+// findTable loads the table given by the table number in the URL and sets its ETag, writing an error response if it
+// can't or the request's If-Match doesn't match it
+func findTable(w http.ResponseWriter, r *http.Request) (model.Table, bool) {
+	tableNumber, err := strconv.Atoi(mux.Vars(r)["tableNumber"])
+	if err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("failed to parse table number: %v", err))
+		return model.Table{}, false
+	}
+
+	table, err := service.GetTable(requestDB(r), tableNumber)
+	if err == nil {
+		err = checkIfMatch(r, table.Version, "table")
+	}
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return table, false
+	}
+	setETag(w, table.Version)
+	return table, true
+}
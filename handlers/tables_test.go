@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"github.com/gorilla/mux"
-	"github.com/ctompkinson/guest-list/database"
 	"github.com/ctompkinson/guest-list/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -15,7 +13,7 @@ import (
 )
 
 func Test_handleCreateTable(t *testing.T) {
-	database.Init()
+	t.Parallel()
 
 	cases := []struct {
 		name           string
@@ -29,7 +27,8 @@ func Test_handleCreateTable(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
+			t.Parallel()
+			_, router := newTestRouter(t)
 
 			body, err := json.Marshal(createTableRequest{Seats: 10})
 			require.NoError(t, err)
@@ -37,8 +36,6 @@ func Test_handleCreateTable(t *testing.T) {
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/table/{tableNumber}", HandleCreateTable)
 			router.ServeHTTP(rr, req)
 
 			assert.Equal(t, c.expectedStatus, rr.Code)
@@ -48,32 +45,18 @@ func Test_handleCreateTable(t *testing.T) {
 }
 
 func Test_handleCreateTable_Duplicate(t *testing.T) {
-	database.Init()
-	database.ClearAndCreate()
+	t.Parallel()
+	_, router := newTestRouter(t)
 
 	body, err := json.Marshal(createTableRequest{Seats: 10})
 	require.NoError(t, err)
-	req, err := http.NewRequest("POST", "/table/1", bytes.NewBuffer(body))
-	require.NoError(t, err)
-
-	rr := httptest.NewRecorder()
-	router := mux.NewRouter()
-	router.HandleFunc("/table/{tableNumber}", HandleCreateTable)
-	router.ServeHTTP(rr, req)
-	assert.Equal(t, http.StatusOK, rr.Code)
-	router.ServeHTTP(rr, req)
 
-	rr2 := httptest.NewRecorder()
-	router2 := mux.NewRouter()
-	router2.HandleFunc("/table/{tableNumber}", HandleCreateTable)
-	router2.ServeHTTP(rr2, req)
-	assert.Equal(t, http.StatusInternalServerError, rr2.Code)
-	router.ServeHTTP(rr2, req)
+	send(t, router, "POST", "/table/1", string(body), http.StatusOK)
+	send(t, router, "POST", "/table/1", string(body), http.StatusConflict)
 }
 
 func TestHandleDeleteTable(t *testing.T) {
-	database.Init()
-	db := database.Get()
+	t.Parallel()
 
 	cases := []struct {
 		name           string
@@ -81,21 +64,21 @@ func TestHandleDeleteTable(t *testing.T) {
 		expectedStatus int
 	}{
 		{"good", "/table/1", http.StatusOK},
-		{"junk", "/table/junk", http.StatusInternalServerError},
+		{"junk", "/table/junk", http.StatusBadRequest},
+		{"unknown", "/table/2", http.StatusNotFound},
 		{"missing", "/table", http.StatusNotFound},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
-			db.Create(&model.Table{Number: 1, Seats: 0})
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `tables: [{number: 1, seats: 0}]`)
 
 			req, err := http.NewRequest("DELETE", c.url, nil)
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/table/{tableNumber}", HandleDeleteTable)
 			router.ServeHTTP(rr, req)
 
 			assert.Equal(t, c.expectedStatus, rr.Code)
@@ -103,9 +86,69 @@ func TestHandleDeleteTable(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateTable(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		url            string
+		body           string
+		expectedStatus int
+		expectedSeats  map[int]int
+		expectedTable  map[string]int
+	}{
+		{"good", "/table/1", `{ "seats": 8 }`, http.StatusOK, map[int]int{1: 8, 2: 2}, map[string]int{"bob": 1, "alice": 1}},
+		{"renumber", "/table/2", `{ "number": 3 }`, http.StatusOK, map[int]int{1: 5, 3: 2}, map[string]int{"bob": 1, "alice": 1}},
+		{"negative", "/table/1", `{ "seats": -1 }`, http.StatusUnprocessableEntity, map[int]int{1: 5, 2: 2}, map[string]int{"bob": 1, "alice": 1}},
+		{"belowReserved", "/table/1", `{ "seats": 3 }`, http.StatusConflict, map[int]int{1: 5, 2: 2}, map[string]int{"bob": 1, "alice": 1}},
+		{"force", "/table/1?shrink=force", `{ "seats": 3 }`, http.StatusOK, map[int]int{1: 3, 2: 2}, map[string]int{"bob": 1, "alice": 1}},
+		// alice reserved last but bob hasn't arrived, so only bob can be moved
+		{"rebalance", "/table/1?shrink=rebalance", `{ "seats": 3 }`, http.StatusOK, map[int]int{1: 3, 2: 2}, map[string]int{"bob": 2, "alice": 1}},
+		{"rebalanceNoRoom", "/table/1?shrink=rebalance", `{ "seats": 1 }`, http.StatusConflict, map[int]int{1: 5, 2: 2}, map[string]int{"bob": 1, "alice": 1}},
+		{"unknown", "/table/3", `{ "seats": 3 }`, http.StatusNotFound, map[int]int{1: 5, 2: 2}, map[string]int{"bob": 1, "alice": 1}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `
+tables: [{number: 1, seats: 5}, {number: 2, seats: 2}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 1}
+  - {name: alice, table: 1, accompanying_guests: 1}
+arrivals: [{name: alice}]
+`)
+
+			req, err := http.NewRequest("PUT", c.url, bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+
+			var tables []model.Table
+			require.NoError(t, db.Find(&tables).Error)
+			seats := map[int]int{}
+			for _, t := range tables {
+				seats[t.Number] = t.Seats
+			}
+			assert.Equal(t, c.expectedSeats, seats)
+
+			var reservations []model.Reservation
+			require.NoError(t, db.Preload("Table").Find(&reservations).Error)
+			tableNumbers := map[string]int{}
+			for _, r := range reservations {
+				tableNumbers[r.Guest] = r.Table.Number
+			}
+			assert.Equal(t, c.expectedTable, tableNumbers)
+		})
+	}
+}
+
 func TestHandleGetTable(t *testing.T) {
-	database.Init()
-	db := database.Get()
+	t.Parallel()
 
 	cases := []struct {
 		name           string
@@ -114,21 +157,21 @@ func TestHandleGetTable(t *testing.T) {
 		expectBody     bool
 	}{
 		{"good", "/table/1", http.StatusOK, true},
-		{"junk", "/table/junk", http.StatusInternalServerError, false},
+		{"junk", "/table/junk", http.StatusBadRequest, false},
+		{"unknown", "/table/2", http.StatusNotFound, false},
 		{"missing", "/table", http.StatusNotFound, false},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			database.ClearAndCreate()
-			db.Create(&model.Table{Number: 1, Seats: 10})
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `tables: [{number: 1, seats: 10}]`)
 
 			req, err := http.NewRequest("GET", c.url, nil)
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
-			router := mux.NewRouter()
-			router.HandleFunc("/table/{tableNumber}", HandleGetTable)
 			router.ServeHTTP(rr, req)
 
 			require.Equal(t, c.expectedStatus, rr.Code)
@@ -144,3 +187,28 @@ func TestHandleGetTable(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleListTables(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+
+	load(t, db, `
+tables: [{number: 1, seats: 10}, {number: 2, seats: 4}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 2}
+  - {name: alice, table: 1, accompanying_guests: 1}
+arrivals: [{name: alice}]
+`)
+
+	req, err := http.NewRequest("GET", "/tables", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t,
+		`{"tables":[{"number":1,"seats":10,"reserved_seats":5,"arrived_seats":2,"free_seats":5},`+
+			`{"number":2,"seats":4,"reserved_seats":0,"arrived_seats":0,"free_seats":4}]}`,
+		rr.Body.String())
+}
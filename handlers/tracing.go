@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/requestid"
+	"github.com/ctompkinson/guest-list/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Tracing starts a span for every request, continuing the trace from the traceparent header when there is one. The
+// span is named after the route template and its trace ID is added to the request's log lines. Handlers add the guest,
+// table and reservation to it, and database statements are recorded as its children
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeTemplate(r)
+		name := r.Method + " " + route
+		if route == "" {
+			name = r.Method
+		}
+		ctx, span := tracing.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(r.URL.Path),
+		))
+		defer span.End()
+		if id := requestid.FromContext(ctx); id != "" {
+			span.SetAttributes(tracing.RequestID.String(id))
+		}
+		if span.SpanContext().IsValid() {
+			logging.AddField(ctx, "trace_id", span.SpanContext().TraceID().String())
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/tracing"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	router := mux.NewRouter()
+	router.Use(RequestID, Tracing)
+	router.HandleFunc("/guest/{name}", func(w http.ResponseWriter, r *http.Request) {
+		tracing.Guest(r.Context(), mux.Vars(r)["name"])
+		w.WriteHeader(http.StatusOK)
+	}).Methods("PUT")
+	router.HandleFunc("/tables", func(w http.ResponseWriter, r *http.Request) {
+		ErrorResponse(w, r, apierror.Internal(nil, "failed to load tables"))
+	}).Methods("GET")
+
+	req, err := http.NewRequest("PUT", "/guest/bob", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, err = http.NewRequest("GET", "/tables", nil)
+	require.NoError(t, err)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	arrival := spans[0]
+	assert.Equal(t, "PUT /guest/{name}", arrival.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", arrival.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", arrival.Parent().SpanID().String())
+	attributes := map[string]interface{}{}
+	for _, a := range arrival.Attributes() {
+		attributes[string(a.Key)] = a.Value.AsInterface()
+	}
+	assert.Equal(t, "bob", attributes["guestlist.guest.name"])
+	assert.Equal(t, "/guest/{name}", attributes["http.route"])
+	assert.Equal(t, int64(http.StatusOK), attributes["http.response.status_code"])
+	assert.NotEmpty(t, attributes["guestlist.request.id"])
+	assert.Equal(t, codes.Unset, arrival.Status().Code)
+
+	assert.Equal(t, "GET /tables", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/service"
+	"io"
+	"net/http"
+)
+
+type v2ArrivalRequest struct {
+	// AccompanyingGuests is how many guests actually turned up, leave it out if it matches the reservation
+	AccompanyingGuests *int `json:"accompanying_guests"`
+}
+
+type v2ListArrivalsResponse struct {
+	Arrivals   []v2Reservation `json:"arrivals"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type v2SeatsResponse struct {
+	Total    int `json:"total"`
+	Reserved int `json:"reserved"`
+	Arrived  int `json:"arrived"`
+	// Empty counts seats without a guest sat in them right now, reserved seats for guests that haven't arrived are empty
+	Empty int `json:"empty"`
+	// Available counts seats that can still be reserved
+	Available int `json:"available"`
+}
+
+// HandleV2ListArrivals lists a page of guests that have arrived, an arrival is a reservation with an arrival time.
+// See parseReservationQuery for the filters
+func HandleV2ListArrivals(w http.ResponseWriter, r *http.Request) {
+	q, err := parseReservationQuery(r, defaultPageSize)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	arrived := true
+	q.Arrived = &arrived
+
+	reservations, next, err := listReservations(requestDB(r), q)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	arrivals := []v2Reservation{}
+	for _, res := range reservations {
+		arrivals = append(arrivals, newV2Reservation(res))
+	}
+	JSONResponse(w, r, http.StatusOK, v2ListArrivalsResponse{Arrivals: arrivals, NextCursor: next})
+}
+
+// HandleV2GetArrival gets a guest's arrival, guests that have a reservation but haven't arrived are not found
+func HandleV2GetArrival(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := findReservation(w, r)
+	if !ok {
+		return
+	}
+	if reservation.ArrivalTime == nil {
+		ErrorResponse(w, r, apierror.NotFound("guest has not arrived"))
+		return
+	}
+	JSONResponse(w, r, http.StatusOK, newV2Reservation(reservation))
+}
+
+// HandleV2PutArrival checks a guest in, responding with 201 the first time and 200 if they had already arrived
+func HandleV2PutArrival(w http.ResponseWriter, r *http.Request) {
+	// PUT /v2/arrivals/{name}
+	// { "accompanying_guests": int }
+	var reqBody v2ArrivalRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+
+	reservation, ok := findReservation(w, r)
+	if !ok {
+		return
+	}
+	alreadyArrived := reservation.ArrivalTime != nil
+
+	accompanyingGuests := reservation.AccompanyingGuests
+	if reqBody.AccompanyingGuests != nil {
+		accompanyingGuests = *reqBody.AccompanyingGuests
+	}
+
+	reservation, err := service.CheckIn(requestDB(r), reservation, accompanyingGuests)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	setETag(w, reservation.Version)
+	status := http.StatusCreated
+	if alreadyArrived {
+		status = http.StatusOK
+	}
+	JSONResponse(w, r, status, newV2Reservation(reservation))
+}
+
+// HandleV2DeleteArrival undoes a check in, the guest keeps their reservation
+func HandleV2DeleteArrival(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := findReservation(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, err := service.UndoCheckIn(requestDB(r), reservation)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	setETag(w, reservation.Version)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleV2GetSeats counts every seat at the party, how many are reserved, taken, empty and still available
+func HandleV2GetSeats(w http.ResponseWriter, r *http.Request) {
+	count, err := service.CountSeats(requestDB(r))
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	JSONResponse(w, r, http.StatusOK, v2SeatsResponse{
+		Total:     count.Total,
+		Reserved:  count.Reserved,
+		Arrived:   count.Arrived,
+		Empty:     count.Total - count.Arrived,
+		Available: count.Total - count.Reserved,
+	})
+}
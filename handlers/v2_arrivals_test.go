@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleV2Arrivals(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		method           string
+		url              string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{"list", "GET", "/v2/arrivals", "", http.StatusOK, ""},
+		{"get", "GET", "/v2/arrivals/bob", "", http.StatusOK, ""},
+		{"getNotArrived", "GET", "/v2/arrivals/taylor", "", http.StatusNotFound, ""},
+		{"arrive", "PUT", "/v2/arrivals/taylor", "", http.StatusCreated, ""},
+		{"arriveAgain", "PUT", "/v2/arrivals/bob", `{ "accompanying_guests": 2 }`, http.StatusOK, ""},
+		{"arriveTooMany", "PUT", "/v2/arrivals/taylor", `{ "accompanying_guests": 5 }`, http.StatusConflict, ""},
+		{"arriveNoReservation", "PUT", "/v2/arrivals/scott", "", http.StatusNotFound, ""},
+		{"undo", "DELETE", "/v2/arrivals/bob", "", http.StatusNoContent, ""},
+		{"undoNotArrived", "DELETE", "/v2/arrivals/taylor", "", http.StatusNotFound, ""},
+		{"seats", "GET", "/v2/seats", "", http.StatusOK, `{"total":6,"reserved":4,"arrived":2,"empty":4,"available":2}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `
+tables: [{number: 1, seats: 6}]
+reservations:
+  - {name: bob, table: 1, accompanying_guests: 1}
+  - {name: taylor, table: 1, accompanying_guests: 1}
+arrivals: [{name: bob}]
+`)
+
+			req, err := http.NewRequest(c.method, c.url, bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			if c.expectedResponse != "" {
+				assert.Equal(t, c.expectedResponse, strings.TrimSpace(rr.Body.String()))
+			}
+		})
+	}
+}
+
+func TestHandleV2ArrivalETags(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+	load(t, db, `
+tables: [{number: 1, seats: 5}]
+reservations: [{name: bob, table: 1}]
+`)
+
+	send := func(method, ifMatch, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "/v2/arrivals/bob", bytes.NewBuffer([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("If-Match", ifMatch)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("PUT", `"1"`, `{ "accompanying_guests": 1 }`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	// A second door tablet checking bob in with the version it loaded earlier is refused
+	rr = send("PUT", `"1"`, `{ "accompanying_guests": 2 }`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = send("DELETE", `"2"`, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+}
+
+func TestHandleV2PutArrival_Again(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+	load(t, db, `
+tables: [{number: 1, seats: 5}]
+reservations: [{name: bob, table: 1}]
+`)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("PUT", "/v2/arrivals/bob", bytes.NewBuffer([]byte(body)))
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("")
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var arrived model.Reservation
+	require.NoError(t, db.Where("guest = ?", "bob").First(&arrived).Error)
+	require.NotNil(t, arrived.ArrivalTime)
+
+	// Checking in again, or retrying the check in, keeps the time they first arrived
+	time.Sleep(1100 * time.Millisecond)
+	rr = send(`{ "accompanying_guests": 1 }`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = send(`{ "accompanying_guests": 1 }`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var again model.Reservation
+	require.NoError(t, db.Where("guest = ?", "bob").First(&again).Error)
+	require.NotNil(t, again.ArrivalTime)
+	assert.True(t, arrived.ArrivalTime.Equal(*again.ArrivalTime), "%v != %v", arrived.ArrivalTime, again.ArrivalTime)
+	assert.Equal(t, 1, again.AccompanyingGuests)
+
+	// Only the first check in counts as an arrival
+	var checkIns int64
+	db.Model(&model.AuditEntry{}).Where("action = ?", "check_in").Count(&checkIns)
+	assert.Equal(t, int64(1), checkIns)
+}
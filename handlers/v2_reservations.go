@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type v2CreateReservationRequest struct {
+	Name               string `json:"name"`
+	TableNumber        int    `json:"table"`
+	AccompanyingGuests int    `json:"accompanying_guests"`
+}
+
+type v2Reservation struct {
+	Name               string  `json:"name"`
+	TableNumber        int     `json:"table"`
+	AccompanyingGuests int     `json:"accompanying_guests"`
+	ArrivedAt          *string `json:"arrived_at"`
+}
+
+type v2ListReservationsResponse struct {
+	Reservations []v2Reservation `json:"reservations"`
+	NextCursor   string          `json:"next_cursor,omitempty"`
+}
+
+// newV2Reservation formats a reservation for the v2 API, unlike v1 it always includes the table and arrival time
+func newV2Reservation(r model.Reservation) v2Reservation {
+	res := v2Reservation{
+		Name:               r.Guest,
+		TableNumber:        r.Table.Number,
+		AccompanyingGuests: r.AccompanyingGuests,
+	}
+	if r.ArrivalTime != nil {
+		arrivedAt := r.ArrivalTime.Format(time.RFC3339)
+		res.ArrivedAt = &arrivedAt
+	}
+	return res
+}
+
+// HandleV2ListReservations lists a page of reservations whether the guest has arrived or not, see
+// parseReservationQuery for the filters
+func HandleV2ListReservations(w http.ResponseWriter, r *http.Request) {
+	q, err := parseReservationQuery(r, defaultPageSize)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	reservations, next, err := listReservations(requestDB(r), q)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	formattedReservations := []v2Reservation{}
+	for _, res := range reservations {
+		formattedReservations = append(formattedReservations, newV2Reservation(res))
+	}
+	JSONResponse(w, r, http.StatusOK, v2ListReservationsResponse{Reservations: formattedReservations, NextCursor: next})
+}
+
+// HandleV2CreateReservation reserves a table for a guest, responding with 201 and its location
+func HandleV2CreateReservation(w http.ResponseWriter, r *http.Request) {
+	// POST /v2/reservations
+	// { "name": string, "table": int, "accompanying_guests": int }
+	var reqBody v2CreateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+
+	reservation, err := service.CreateReservation(requestDB(r), service.NewReservation{Guest: reqBody.Name, TableNumber: reqBody.TableNumber, AccompanyingGuests: reqBody.AccompanyingGuests})
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v2/reservations/%s", url.PathEscape(reservation.Guest)))
+	setETag(w, reservation.Version)
+	JSONResponse(w, r, http.StatusCreated, newV2Reservation(reservation))
+}
+
+// HandleV2GetReservation gets a guest's reservation
+func HandleV2GetReservation(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := findReservation(w, r)
+	if !ok {
+		return
+	}
+	JSONResponse(w, r, http.StatusOK, newV2Reservation(reservation))
+}
+
+// HandleV2DeleteReservation cancels a guest's reservation, responding with 204
+func HandleV2DeleteReservation(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := findReservation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteReservation(requestDB(r), reservation); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
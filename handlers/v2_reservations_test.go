@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleV2Reservations(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		method           string
+		url              string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+		expectedLocation string
+	}{
+		{"list", "GET", "/v2/reservations", "", http.StatusOK, `{"reservations":[{"name":"bob","table":1,"accompanying_guests":1,"arrived_at":null}]}`, ""},
+		{"create", "POST", "/v2/reservations", `{ "name": "taylor swift", "table": 1, "accompanying_guests": 2 }`, http.StatusCreated, `{"name":"taylor swift","table":1,"accompanying_guests":2,"arrived_at":null}`, "/v2/reservations/taylor%20swift"},
+		{"createDuplicate", "POST", "/v2/reservations", `{ "name": "bob", "table": 1 }`, http.StatusConflict, "", ""},
+		{"createFull", "POST", "/v2/reservations", `{ "name": "taylor", "table": 1, "accompanying_guests": 3 }`, http.StatusConflict, "", ""},
+		{"createUnknownTable", "POST", "/v2/reservations", `{ "name": "taylor", "table": 2 }`, http.StatusNotFound, "", ""},
+		{"createNoName", "POST", "/v2/reservations", `{ "table": 1 }`, http.StatusUnprocessableEntity, "", ""},
+		{"get", "GET", "/v2/reservations/bob", "", http.StatusOK, `{"name":"bob","table":1,"accompanying_guests":1,"arrived_at":null}`, ""},
+		{"getMissing", "GET", "/v2/reservations/taylor", "", http.StatusNotFound, "", ""},
+		{"delete", "DELETE", "/v2/reservations/bob", "", http.StatusNoContent, "", ""},
+		{"deleteMissing", "DELETE", "/v2/reservations/taylor", "", http.StatusNotFound, "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `
+tables: [{number: 1, seats: 5}]
+reservations: [{name: bob, table: 1, accompanying_guests: 1}]
+`)
+
+			req, err := http.NewRequest(c.method, c.url, bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			if c.expectedResponse != "" {
+				assert.Equal(t, c.expectedResponse, strings.TrimSpace(rr.Body.String()))
+			}
+			assert.Equal(t, c.expectedLocation, rr.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
+	"net/http"
+)
+
+type v2CreateTableRequest struct {
+	Number int `json:"number"`
+	Seats  int `json:"seats"`
+}
+
+type v2UpdateTableResponse struct {
+	model.FormattedTable
+	// Moved is every reservation that was moved to another table to make room
+	Moved []v2Reservation `json:"moved,omitempty"`
+}
+
+// HandleV2ListTables lists every table ordered by number, with how many of its seats are reserved, taken and free
+func HandleV2ListTables(w http.ResponseWriter, r *http.Request) {
+	summaries, err := service.ListTables(requestDB(r))
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	JSONResponse(w, r, http.StatusOK, map[string][]model.TableSummary{"tables": summaries})
+}
+
+// HandleV2CreateTable creates a table, responding with 201 and its location
+func HandleV2CreateTable(w http.ResponseWriter, r *http.Request) {
+	// POST /v2/tables
+	// { "number": int, "seats": int }
+	var reqBody v2CreateTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+
+	table, err := service.CreateTable(requestDB(r), service.NewTable{Number: reqBody.Number, Seats: reqBody.Seats})
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v2/tables/%d", table.Number))
+	setETag(w, table.Version)
+	JSONResponse(w, r, http.StatusCreated, table.FormatAsTable())
+}
+
+// HandleV2GetTable gets a table by its number
+func HandleV2GetTable(w http.ResponseWriter, r *http.Request) {
+	table, ok := findTable(w, r)
+	if !ok {
+		return
+	}
+	JSONResponse(w, r, http.StatusOK, table.FormatAsTable())
+}
+
+// HandleV2UpdateTable changes a table's number or seats, see HandleUpdateTable for the shrink options. The response
+// includes any reservations that were moved to other tables. If the number changes so does the table's location
+func HandleV2UpdateTable(w http.ResponseWriter, r *http.Request) {
+	// PATCH /v2/tables/{tableNumber}?shrink=force|rebalance
+	// { "number": int, "seats": int }
+	table, ok := findTable(w, r)
+	if !ok {
+		return
+	}
+
+	var reqBody updateTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+
+	update := service.TableUpdate{Number: reqBody.Number, Seats: reqBody.Seats, Shrink: service.ShrinkMode(r.URL.Query().Get("shrink"))}
+	table, moved, err := service.UpdateTable(requestDB(r), table, update)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	res := v2UpdateTableResponse{FormattedTable: table.FormatAsTable()}
+	for _, reservation := range moved {
+		res.Moved = append(res.Moved, newV2Reservation(reservation))
+	}
+	w.Header().Set("Location", fmt.Sprintf("/v2/tables/%d", table.Number))
+	setETag(w, table.Version)
+	JSONResponse(w, r, http.StatusOK, res)
+}
+
+// HandleV2DeleteTable deletes a table that has no reservations, responding with 204
+func HandleV2DeleteTable(w http.ResponseWriter, r *http.Request) {
+	table, ok := findTable(w, r)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteTable(requestDB(r), table); err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
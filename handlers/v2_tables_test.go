@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleV2Tables(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		method           string
+		url              string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+		expectedLocation string
+	}{
+		{"list", "GET", "/v2/tables", "", http.StatusOK, `{"tables":[{"number":1,"seats":5,"reserved_seats":1,"arrived_seats":0,"free_seats":4},{"number":2,"seats":4,"reserved_seats":0,"arrived_seats":0,"free_seats":4}]}`, ""},
+		{"create", "POST", "/v2/tables", `{ "number": 3, "seats": 6 }`, http.StatusCreated, `{"number":3,"seats":6}`, "/v2/tables/3"},
+		{"createDuplicate", "POST", "/v2/tables", `{ "number": 1, "seats": 6 }`, http.StatusConflict, "", ""},
+		{"createInvalid", "POST", "/v2/tables", `{ "number": 0, "seats": 6 }`, http.StatusUnprocessableEntity, "", ""},
+		{"get", "GET", "/v2/tables/2", "", http.StatusOK, `{"number":2,"seats":4}`, ""},
+		{"getMissing", "GET", "/v2/tables/3", "", http.StatusNotFound, "", ""},
+		{"update", "PATCH", "/v2/tables/2", `{ "seats": 8 }`, http.StatusOK, `{"number":2,"seats":8}`, "/v2/tables/2"},
+		{"renumber", "PATCH", "/v2/tables/2", `{ "number": 7 }`, http.StatusOK, `{"number":7,"seats":4}`, "/v2/tables/7"},
+		{"renumberDuplicate", "PATCH", "/v2/tables/2", `{ "number": 1 }`, http.StatusConflict, "", ""},
+		{"shrinkBelowReserved", "PATCH", "/v2/tables/1", `{ "seats": 0 }`, http.StatusConflict, "", ""},
+		{"shrinkForce", "PATCH", "/v2/tables/1?shrink=force", `{ "seats": 0 }`, http.StatusOK, `{"number":1,"seats":0}`, "/v2/tables/1"},
+		{"shrinkRebalance", "PATCH", "/v2/tables/1?shrink=rebalance", `{ "seats": 0 }`, http.StatusOK,
+			`{"number":1,"seats":0,"moved":[{"name":"bob","table":2,"accompanying_guests":0,"arrived_at":null}]}`, "/v2/tables/1"},
+		{"shrinkUnknown", "PATCH", "/v2/tables/1?shrink=squeeze", `{ "seats": 1 }`, http.StatusUnprocessableEntity, "", ""},
+		{"delete", "DELETE", "/v2/tables/2", "", http.StatusNoContent, "", ""},
+		{"deleteWithReservation", "DELETE", "/v2/tables/1", "", http.StatusConflict, "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db, router := newTestRouter(t)
+			load(t, db, `
+tables: [{number: 1, seats: 5}, {number: 2, seats: 4}]
+reservations: [{name: bob, table: 1}]
+`)
+
+			req, err := http.NewRequest(c.method, c.url, bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			if c.expectedResponse != "" {
+				assert.Equal(t, c.expectedResponse, strings.TrimSpace(rr.Body.String()))
+			}
+			assert.Equal(t, c.expectedLocation, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHandleV2TableETags(t *testing.T) {
+	t.Parallel()
+	db, router := newTestRouter(t)
+	load(t, db, `tables: [{number: 1, seats: 5}]`)
+
+	send := func(method, url, ifMatch, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, bytes.NewBuffer([]byte(body)))
+		require.NoError(t, err)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("GET", "/v2/tables/1", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+
+	rr = send("PATCH", "/v2/tables/1", `"1"`, `{ "seats": 6 }`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	// Someone still holding version 1 can't overwrite the change
+	rr = send("PATCH", "/v2/tables/1", `"1"`, `{ "seats": 8 }`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	rr = send("DELETE", "/v2/tables/1", `"1"`, "")
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = send("DELETE", "/v2/tables/1", `"2"`, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+type createWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// HandleCreateWebhook registers a new subscription that will be sent reservation, arrival and table events
+// if no secret is given one is generated and returned, it is not possible to retrieve it again
+func HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+	// POST /webhooks
+	// { "url": string, "secret": string, "events": []string }
+
+	var reqBody createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		ErrorResponse(w, r, apierror.BadRequest("unable to parse body: %v", err))
+		return
+	}
+
+	if err := webhooks.ValidateURL(reqBody.URL); err != nil {
+		ErrorResponse(w, r, apierror.Validation("%v", err))
+		return
+	}
+
+	for _, e := range reqBody.Events {
+		if !webhooks.IsEvent(e) {
+			ErrorResponse(w, r, apierror.Validation("unknown event: %s", e))
+			return
+		}
+	}
+
+	secret := reqBody.Secret
+	if secret == "" {
+		var err error
+		secret, err = webhooks.NewSecret()
+		if err != nil {
+			ErrorResponse(w, r, apierror.Internal(err, "failed to generate secret"))
+			return
+		}
+	}
+
+	subscription := model.WebhookSubscription{
+		URL:    reqBody.URL,
+		Secret: secret,
+		Events: strings.Join(reqBody.Events, ","),
+	}
+	if err := db.Create(&subscription).Error; err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to create webhook"))
+		return
+	}
+
+	// Only time the secret is ever given back, so it isn't saved for idempotent retries or cached either
+	w.Header().Set("Cache-Control", "no-store")
+	formatted := subscription.FormatAsWebhookSubscription()
+	formatted.Secret = secret
+	out, err := json.Marshal(formatted)
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal response"))
+		return
+	}
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// HandleListWebhooks lists all the registered webhook subscriptions
+func HandleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	var subscriptions []model.WebhookSubscription
+	if err := db.Find(&subscriptions).Error; err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to load webhooks"))
+		return
+	}
+
+	formattedSubscriptions := []model.FormattedWebhookSubscription{}
+	for _, s := range subscriptions {
+		formattedSubscriptions = append(formattedSubscriptions, s.FormatAsWebhookSubscription())
+	}
+
+	out, err := json.Marshal(map[string][]model.FormattedWebhookSubscription{
+		"webhooks": formattedSubscriptions,
+	})
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal webhooks"))
+		return
+	}
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// HandleDeleteWebhook removes a webhook subscription given its id, its delivery log is removed with it
+func HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	subscription, ok := findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("webhook_subscription_id = ?", subscription.ID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&subscription).Error
+	})
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to delete webhook"))
+		return
+	}
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write([]byte(`{"message":"deleted"}`))
+}
+
+// HandleListWebhookDeliveries lists every delivery attempt made to a webhook subscription, newest first
+func HandleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	db := requestDB(r)
+
+	subscription, ok := findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var deliveries []model.WebhookDelivery
+	if err := db.Where("webhook_subscription_id = ?", subscription.ID).Order("id desc").Find(&deliveries).Error; err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to load deliveries"))
+		return
+	}
+
+	formattedDeliveries := []model.FormattedWebhookDelivery{}
+	for _, d := range deliveries {
+		formattedDeliveries = append(formattedDeliveries, d.FormatAsWebhookDelivery())
+	}
+
+	out, err := json.Marshal(map[string][]model.FormattedWebhookDelivery{
+		"deliveries": formattedDeliveries,
+	})
+	if err != nil {
+		ErrorResponse(w, r, apierror.Internal(err, "failed to marshal deliveries"))
+		return
+	}
+
+	http.StatusText(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// findWebhook loads the subscription given by the id in the URL, writing an error response if it can't
+func findWebhook(w http.ResponseWriter, r *http.Request) (model.WebhookSubscription, bool) {
+	db := requestDB(r)
+
+	var subscription model.WebhookSubscription
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		ErrorResponse(w, r, apierror.BadRequest("unable to retrieve webhook id from URL"))
+		return subscription, false
+	}
+
+	if err := db.Where("id = ?", id).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ErrorResponse(w, r, apierror.NotFound("webhook does not exist"))
+			return subscription, false
+		}
+		ErrorResponse(w, r, apierror.Internal(err, "failed to lookup webhook"))
+		return subscription, false
+	}
+	return subscription, true
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandleCreateWebhook(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			"good",
+			`{ "url": "http://203.0.113.10/hook", "secret": "foo", "events": ["guest.arrived"] }`,
+			http.StatusOK,
+			`{"id":1,"url":"http://203.0.113.10/hook","events":["guest.arrived"],"secret":"foo"}`,
+		},
+		{
+			"badUrl",
+			`{ "url": "localhost/hook" }`,
+			http.StatusUnprocessableEntity,
+			`{"type":"urn:guest-list:problem:validation","title":"Unprocessable Entity","status":422,"detail":"url must be an absolute http or https url","instance":"/webhooks","code":"validation"}`,
+		},
+		{
+			"unknownEvent",
+			`{ "url": "http://203.0.113.10/hook", "events": ["guest.left"] }`,
+			http.StatusUnprocessableEntity,
+			`{"type":"urn:guest-list:problem:validation","title":"Unprocessable Entity","status":422,"detail":"unknown event: guest.left","instance":"/webhooks","code":"validation"}`,
+		},
+		{
+			"metadataEndpoint",
+			`{ "url": "http://169.254.169.254/latest/meta-data" }`,
+			http.StatusUnprocessableEntity,
+			`{"type":"urn:guest-list:problem:validation","title":"Unprocessable Entity","status":422,"detail":"url must not point at a loopback, private or link-local address","instance":"/webhooks","code":"validation"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			_, router := newTestRouter(t)
+
+			req, err := http.NewRequest("POST", "/webhooks", bytes.NewBuffer([]byte(c.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedStatus, rr.Code)
+			assert.Equal(t, c.expectedResponse, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
+
+// Not parallel, the test receiver listens on localhost so private targets are allowed while it runs
+func TestWebhookDelivery(t *testing.T) {
+	webhooks.AllowPrivate = true
+	defer func() { webhooks.AllowPrivate = false }()
+	db, router := newTestRouter(t)
+
+	var received []byte
+	var signature, timestamp string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get(webhooks.SignatureHeader)
+		timestamp = r.Header.Get(webhooks.TimestampHeader)
+	}))
+	defer receiver.Close()
+
+	load(t, db, `tables: [{number: 1, seats: 5}]`)
+	db.Create(&model.WebhookSubscription{URL: receiver.URL, Secret: "foo", Events: webhooks.EventReservationCreated})
+
+	req, err := http.NewRequest("POST", "/guest_list/bob", bytes.NewBuffer([]byte(`{ "table": 1, "accompanying_guests": 1 }`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	webhooks.Wait()
+
+	var event webhooks.Event
+	require.NoError(t, json.Unmarshal(received, &event))
+	assert.Equal(t, webhooks.EventReservationCreated, event.Type)
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.Sign("foo", ts, received), signature)
+
+	req, err = http.NewRequest("GET", "/webhooks/1/deliveries", nil)
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var out map[string][]model.FormattedWebhookDelivery
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+	require.Len(t, out["deliveries"], 1)
+	assert.True(t, out["deliveries"][0].Succeeded)
+}
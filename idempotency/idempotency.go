@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/ctompkinson/guest-list/model"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+const (
+	// Header is the request header clients put a unique key in to make a request safe to retry
+	Header = "Idempotency-Key"
+	// ReplayedHeader is added to responses that were saved from an earlier request
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength is the longest key that can be stored
+	MaxKeyLength = 255
+)
+
+var (
+	// Window is how long a response is kept for, retries after that are handled as new requests
+	Window = 24 * time.Hour
+
+	// ErrInProgress is returned when the first request with a key hasn't finished yet
+	ErrInProgress = errors.New("a request with this idempotency key is still being handled")
+	// ErrMismatch is returned when a key is reused by someone else or for a different method, path or body
+	ErrMismatch = errors.New("this idempotency key was already used for a different request")
+)
+
+// Fingerprint hashes a request body so retries can be told apart from a different request reusing a key
+func Fingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin claims a key for a request. If the same principal has already made the same request with the key its saved
+// response is returned with replay set, otherwise a new record is created and the caller must Complete or Release it
+func Begin(db *gorm.DB, key, principal, method, path, hash string) (record model.IdempotencyKey, replay bool, err error) {
+	now := time.Now()
+
+	err = db.Where(&model.IdempotencyKey{Key: key}).First(&record).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return record, false, err
+	}
+	if err == nil {
+		if record.ExpiresAt.After(now) {
+			if record.Principal != principal || record.Method != method || record.Path != path || record.RequestHash != hash {
+				return record, false, ErrMismatch
+			}
+			if !record.Completed {
+				return record, false, ErrInProgress
+			}
+			return record, true, nil
+		}
+
+		// The key has expired so it can be used again
+		if err := db.Delete(&record).Error; err != nil {
+			return record, false, err
+		}
+	}
+
+	record = model.IdempotencyKey{
+		Key:         key,
+		Principal:   principal,
+		Method:      method,
+		Path:        path,
+		RequestHash: hash,
+		ExpiresAt:   now.Add(Window),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		// Another request may have claimed the key between looking it up and creating it
+		var count int64
+		if countErr := db.Model(&model.IdempotencyKey{}).Where(&model.IdempotencyKey{Key: key}).Count(&count).Error; countErr == nil && count > 0 {
+			return record, false, ErrInProgress
+		}
+		return record, false, err
+	}
+	return record, false, nil
+}
+
+// Complete saves the response to a request so it can be replayed
+func Complete(db *gorm.DB, record model.IdempotencyKey, status int, header http.Header, body []byte) error {
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return db.Model(&record).Updates(map[string]interface{}{
+		"completed":   true,
+		"status_code": status,
+		"header":      string(encoded),
+		"body":        body,
+	}).Error
+}
+
+// Release forgets a key so the request can be tried again, for when handling it failed
+func Release(db *gorm.DB, record model.IdempotencyKey) error {
+	return db.Delete(&record).Error
+}
+
+// Headers decodes the saved response headers of a record
+func Headers(record model.IdempotencyKey) (http.Header, error) {
+	header := http.Header{}
+	if record.Header == "" {
+		return header, nil
+	}
+	err := json.Unmarshal([]byte(record.Header), &header)
+	return header, err
+}
+
+// Purge deletes every key that expired before the given time, returning how many were deleted
+func Purge(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("expires_at < ?", before).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
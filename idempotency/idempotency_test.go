@@ -0,0 +1,28 @@
+package idempotency
+
+import (
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	body := []byte(`{ "table": 1, "accompanying_guests": 2 }`)
+
+	assert.Equal(t, Fingerprint(body), Fingerprint(body))
+	assert.NotEqual(t, Fingerprint(body), Fingerprint([]byte(`{ "table": 2, "accompanying_guests": 2 }`)))
+	assert.Len(t, Fingerprint(nil), 64)
+}
+
+func TestHeaders(t *testing.T) {
+	header, err := Headers(model.IdempotencyKey{Header: `{"Content-Type":["application/json"],"Location":["/v2/tables/1"]}`})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "/v2/tables/1", header.Get("Location"))
+
+	header, err = Headers(model.IdempotencyKey{})
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{}, header)
+}
@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/service"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	TablesFile       = "tables"
+	ReservationsFile = "guest_list"
+)
+
+var (
+	tableColumns       = []string{"table", "seats"}
+	reservationColumns = []string{"name", "table", "accompanying_guests"}
+)
+
+type TableRow struct {
+	Line   int
+	Number int
+	Seats  int
+}
+
+type ReservationRow struct {
+	Line               int
+	Guest              string
+	TableNumber        int
+	AccompanyingGuests int
+}
+
+// Plan is everything that will be created by an import
+type Plan struct {
+	Tables       []TableRow
+	Reservations []ReservationRow
+}
+
+type RowError struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type Report struct {
+	DryRun              bool       `json:"dry_run"`
+	Valid               bool       `json:"valid"`
+	TablesCreated       int        `json:"tables_created"`
+	ReservationsCreated int        `json:"reservations_created"`
+	Errors              []RowError `json:"errors"`
+}
+
+// ErrInvalid is returned by Apply when the plan didn't pass validation, nothing will have been written
+var ErrInvalid = errors.New("import contains invalid rows")
+
+// ParseTables reads a tables CSV with a header containing table and seats columns
+func ParseTables(r io.Reader) ([]TableRow, []RowError) {
+	var rows []TableRow
+	errs := parse(r, TablesFile, tableColumns, func(line int, values map[string]string) []string {
+		var problems []string
+		number, err := strconv.Atoi(values["table"])
+		if err != nil || number < 1 {
+			problems = append(problems, fmt.Sprintf("invalid table number %q", values["table"]))
+		}
+		seats, err := strconv.Atoi(values["seats"])
+		if err != nil || seats < 0 {
+			problems = append(problems, fmt.Sprintf("invalid seats %q", values["seats"]))
+		}
+		if len(problems) == 0 {
+			rows = append(rows, TableRow{Line: line, Number: number, Seats: seats})
+		}
+		return problems
+	})
+	return rows, errs
+}
+
+// ParseReservations reads a guest list CSV with a header containing name, table and accompanying_guests columns
+func ParseReservations(r io.Reader) ([]ReservationRow, []RowError) {
+	var rows []ReservationRow
+	errs := parse(r, ReservationsFile, reservationColumns, func(line int, values map[string]string) []string {
+		var problems []string
+		if values["name"] == "" {
+			problems = append(problems, "missing guest name")
+		}
+		number, err := strconv.Atoi(values["table"])
+		if err != nil || number < 1 {
+			problems = append(problems, fmt.Sprintf("invalid table number %q", values["table"]))
+		}
+		guests := 0
+		if values["accompanying_guests"] != "" {
+			guests, err = strconv.Atoi(values["accompanying_guests"])
+			if err != nil || guests < 0 {
+				problems = append(problems, fmt.Sprintf("invalid accompanying guests %q", values["accompanying_guests"]))
+			}
+		}
+		if len(problems) == 0 {
+			rows = append(rows, ReservationRow{Line: line, Guest: values["name"], TableNumber: number, AccompanyingGuests: guests})
+		}
+		return problems
+	})
+	return rows, errs
+}
+
+// errDryRun rolls back the transaction Validate creates everything in
+var errDryRun = errors.New("dry run")
+
+// Validate checks every row of the plan against itself and what is already in the database, reporting duplicate
+// tables and guests along with every row the service package would refuse, such as reservations for unknown tables
+// and tables that would be over capacity. Everything is created in a transaction that is always rolled back, so the
+// rows are checked by exactly the same rules Apply uses
+func Validate(db *gorm.DB, plan Plan) ([]RowError, error) {
+	var errs []RowError
+	err := service.Transaction(db, func(tx *gorm.DB) error {
+		var err error
+		if _, _, errs, err = create(tx, plan); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// Apply creates everything in the plan inside a single transaction, either every row is imported or none are. Rows
+// are created with the service package, so they follow the same rules and send the same webhooks as ones made through
+// the API
+func Apply(db *gorm.DB, plan Plan) ([]model.Table, []model.Reservation, error) {
+	var tables []model.Table
+	var reservations []model.Reservation
+
+	err := service.Transaction(db, func(tx *gorm.DB) error {
+		var errs []RowError
+		var err error
+		tables, reservations, errs, err = create(tx, plan)
+		if err != nil {
+			return err
+		}
+		if len(errs) > 0 {
+			return ErrInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return tables, reservations, nil
+}
+
+// create makes every table and then every reservation in the plan. A row that is refused is reported and the rest
+// carry on being created, so every problem with the plan is found at once, only unexpected errors stop it
+func create(tx *gorm.DB, plan Plan) ([]model.Table, []model.Reservation, []RowError, error) {
+	errs := []RowError{}
+	var tables []model.Table
+	var reservations []model.Reservation
+
+	seenTables := map[int]int{}
+	for _, t := range plan.Tables {
+		if line, ok := seenTables[t.Number]; ok {
+			errs = append(errs, RowError{TablesFile, t.Line, fmt.Sprintf("table %d is duplicated on line %d", t.Number, line)})
+			continue
+		}
+		seenTables[t.Number] = t.Line
+
+		table, err := service.CreateTable(tx, service.NewTable{Number: t.Number, Seats: t.Seats})
+		if err != nil {
+			if apierror.KindOf(err) == apierror.KindInternal {
+				return nil, nil, nil, err
+			}
+			errs = append(errs, RowError{TablesFile, t.Line, apierror.Message(err)})
+			continue
+		}
+		tables = append(tables, table)
+	}
+
+	seenGuests := map[string]int{}
+	for _, res := range plan.Reservations {
+		if line, ok := seenGuests[res.Guest]; ok {
+			errs = append(errs, RowError{ReservationsFile, res.Line, fmt.Sprintf("guest %s is duplicated on line %d", res.Guest, line)})
+			continue
+		}
+		seenGuests[res.Guest] = res.Line
+
+		reservation, err := service.CreateReservation(tx, service.NewReservation{
+			Guest:              res.Guest,
+			TableNumber:        res.TableNumber,
+			AccompanyingGuests: res.AccompanyingGuests,
+		})
+		if err != nil {
+			if apierror.KindOf(err) == apierror.KindInternal {
+				return nil, nil, nil, err
+			}
+			errs = append(errs, RowError{ReservationsFile, res.Line, apierror.Message(err)})
+			continue
+		}
+		reservations = append(reservations, reservation)
+	}
+	return tables, reservations, errs, nil
+}
+
+// parse reads a CSV file with a header row, calling row for every record with its values keyed by column name
+func parse(r io.Reader, file string, columns []string, row func(line int, values map[string]string) []string) []RowError {
+	errs := []RowError{}
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return append(errs, RowError{file, 1, fmt.Sprintf("failed to read header: %v", err)})
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, c := range columns {
+		if _, ok := index[c]; !ok {
+			errs = append(errs, RowError{file, 1, fmt.Sprintf("missing column %s", c)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		line++
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, RowError{file, line, fmt.Sprintf("failed to read row: %v", err)})
+			continue
+		}
+
+		values := map[string]string{}
+		for _, c := range columns {
+			if index[c] < len(record) {
+				values[c] = strings.TrimSpace(record[index[c]])
+			}
+		}
+		for _, problem := range row(line, values) {
+			errs = append(errs, RowError{file, line, problem})
+		}
+	}
+	return errs
+}
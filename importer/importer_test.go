@@ -0,0 +1,31 @@
+package importer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseTables(t *testing.T) {
+	rows, errs := ParseTables(strings.NewReader("seats,table\n10,1\n4, 2\nfoo,3\n"))
+
+	assert.Equal(t, []TableRow{{Line: 2, Number: 1, Seats: 10}, {Line: 3, Number: 2, Seats: 4}}, rows)
+	assert.Equal(t, []RowError{{TablesFile, 4, `invalid seats "foo"`}}, errs)
+}
+
+func TestParseTables_MissingColumn(t *testing.T) {
+	rows, errs := ParseTables(strings.NewReader("table\n1\n"))
+
+	assert.Empty(t, rows)
+	assert.Equal(t, []RowError{{TablesFile, 1, "missing column seats"}}, errs)
+}
+
+func TestParseReservations(t *testing.T) {
+	rows, errs := ParseReservations(strings.NewReader("name,table,accompanying_guests\nbob,1,2\ntaylor,1,\n,2,1\n"))
+
+	assert.Equal(t, []ReservationRow{
+		{Line: 2, Guest: "bob", TableNumber: 1, AccompanyingGuests: 2},
+		{Line: 3, Guest: "taylor", TableNumber: 1, AccompanyingGuests: 0},
+	}, rows)
+	assert.Equal(t, []RowError{{ReservationsFile, 4, "missing guest name"}}, errs)
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/ctompkinson/guest-list/requestid"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sync"
+)
+
+// Log is the logger every package writes to, Configure sets its format and level
+var Log = logrus.New()
+
+// Configure sets the output format, json or text, and the lowest level that is logged
+func Configure(format, level string) error {
+	switch format {
+	case "", "text":
+		Log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	case "json":
+		Log.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q, must be json or text", format)
+	}
+
+	if level == "" {
+		level = "info"
+	}
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	Log.SetLevel(lvl)
+	return nil
+}
+
+// ConfigureFromEnv configures logging from GUESTLIST_LOG_FORMAT and GUESTLIST_LOG_LEVEL
+func ConfigureFromEnv() error {
+	Log.SetOutput(os.Stdout)
+	return Configure(os.Getenv("GUESTLIST_LOG_FORMAT"), os.Getenv("GUESTLIST_LOG_LEVEL"))
+}
+
+// requestFields are added to every line logged during a request. They are shared by pointer so middleware further in,
+// such as authentication, can add to what the access log sees
+type requestFields struct {
+	mu     sync.Mutex
+	fields logrus.Fields
+}
+
+type fieldsKey struct{}
+
+// WithFields starts collecting fields for a request
+func WithFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &requestFields{fields: logrus.Fields{}})
+}
+
+// AddField adds a field to every line logged for the request from now on, it does nothing outside of a request
+func AddField(ctx context.Context, key string, value interface{}) {
+	if f, ok := ctx.Value(fieldsKey{}).(*requestFields); ok {
+		f.mu.Lock()
+		f.fields[key] = value
+		f.mu.Unlock()
+	}
+}
+
+// FromContext gets a logger for a request, with its ID and any fields that have been added to it
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(Log)
+	if ctx == nil {
+		return entry
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		entry = entry.WithField("request_id", id)
+	}
+	if f, ok := ctx.Value(fieldsKey{}).(*requestFields); ok {
+		f.mu.Lock()
+		entry = entry.WithFields(f.fields)
+		f.mu.Unlock()
+	}
+	return entry
+}
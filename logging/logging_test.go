@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/requestid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	defer func() { _ = Configure("text", "info") }()
+
+	cases := []struct {
+		name          string
+		format        string
+		level         string
+		expectedLevel logrus.Level
+		expectedErr   bool
+	}{
+		{"defaults", "", "", logrus.InfoLevel, false},
+		{"json", "json", "debug", logrus.DebugLevel, false},
+		{"text", "text", "warn", logrus.WarnLevel, false},
+		{"unknownFormat", "xml", "", logrus.InfoLevel, true},
+		{"unknownLevel", "json", "chatty", logrus.InfoLevel, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Configure(c.format, c.level)
+			if c.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedLevel, Log.GetLevel())
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	Log.SetOutput(&buf)
+	require.NoError(t, Configure("json", "info"))
+	defer func() {
+		Log.SetOutput(os.Stderr)
+		_ = Configure("text", "info")
+	}()
+
+	// Fields can't be added outside of a request
+	AddField(context.Background(), "actor", "nobody")
+	FromContext(context.Background()).Info("outside")
+
+	ctx := WithFields(requestid.WithID(context.Background(), "abc123"))
+	AddField(ctx, "actor", "door")
+	FromContext(ctx).Info("inside")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var outside, inside map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &outside))
+	require.NoError(t, json.Unmarshal(lines[1], &inside))
+	assert.NotContains(t, outside, "actor")
+	assert.NotContains(t, outside, "request_id")
+	assert.Equal(t, "door", inside["actor"])
+	assert.Equal(t, "abc123", inside["request_id"])
+	assert.Equal(t, "inside", inside["msg"])
+}
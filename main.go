@@ -1,7 +1,10 @@
 package main
 
-import "github.com/ctompkinson/guest-list/server"
+import (
+	"github.com/ctompkinson/guest-list/cli"
+	"os"
+)
 
 func main() {
-	server.Start()
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
 }
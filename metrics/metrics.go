@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const namespace = "guestlist"
+
+// Registry holds every metric the server exposes, along with the Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts handled requests by method, route template and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration is how long requests took to handle by method and route template
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "How long requests took to handle, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// DBQueryDuration is how long database statements took by operation and table
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "How long database statements took, by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	tableSeats = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "table_seats",
+		Help:      "Seats at each table.",
+	}, []string{"table"})
+	tableReservedSeats = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "table_reserved_seats",
+		Help:      "Seats reserved at each table, including accompanying guests.",
+	}, []string{"table"})
+	tableArrivedGuests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "table_arrived_guests",
+		Help:      "Guests that have arrived at each table, including accompanying guests.",
+	}, []string{"table"})
+	tableEmptySeats = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "table_empty_seats",
+		Help:      "Seats at each table not taken by a guest that has arrived.",
+	}, []string{"table"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		DBQueryDuration,
+		tableSeats,
+		tableReservedSeats,
+		tableArrivedGuests,
+		tableEmptySeats,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+var (
+	tablesMu sync.Mutex
+	// tables are the labels the table gauges were last set for, so deleted tables can be dropped
+	tables = map[string]bool{}
+)
+
+// SetTables sets the seat gauges to the current state of every table, tables that are no longer in the list are
+// removed
+func SetTables(summaries []model.TableSummary) {
+	tablesMu.Lock()
+	defer tablesMu.Unlock()
+
+	current := map[string]bool{}
+	for _, s := range summaries {
+		label := strconv.Itoa(s.Number)
+		current[label] = true
+		tableSeats.WithLabelValues(label).Set(float64(s.Seats))
+		tableReservedSeats.WithLabelValues(label).Set(float64(s.ReservedSeats))
+		tableArrivedGuests.WithLabelValues(label).Set(float64(s.ArrivedSeats))
+		tableEmptySeats.WithLabelValues(label).Set(float64(s.Seats - s.ArrivedSeats))
+	}
+
+	for label := range tables {
+		if current[label] {
+			continue
+		}
+		for _, gauge := range []*prometheus.GaugeVec{tableSeats, tableReservedSeats, tableArrivedGuests, tableEmptySeats} {
+			gauge.DeleteLabelValues(label)
+		}
+	}
+	tables = current
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSetTables(t *testing.T) {
+	SetTables([]model.TableSummary{
+		{Number: 1, Seats: 4, ReservedSeats: 3, ArrivedSeats: 2},
+		{Number: 2, Seats: 6},
+	})
+	assert.Equal(t, 4.0, testutil.ToFloat64(tableSeats.WithLabelValues("1")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(tableReservedSeats.WithLabelValues("1")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(tableArrivedGuests.WithLabelValues("1")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(tableEmptySeats.WithLabelValues("1")))
+	assert.Equal(t, 6.0, testutil.ToFloat64(tableEmptySeats.WithLabelValues("2")))
+
+	// Table 1 has been deleted
+	SetTables([]model.TableSummary{{Number: 2, Seats: 6, ReservedSeats: 1, ArrivedSeats: 1}})
+	assert.Equal(t, 1, testutil.CollectAndCount(tableSeats))
+	assert.Equal(t, 1, testutil.CollectAndCount(tableEmptySeats))
+	assert.Equal(t, 5.0, testutil.ToFloat64(tableEmptySeats.WithLabelValues("2")))
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type APIKey struct {
+	gorm.Model
+	Name   string
+	Role   string
+	Prefix string // The start of the key so it can be recognised, the rest is only ever stored hashed
+	Hash   string `gorm:"size:64;uniqueIndex"`
+	// LastUsedAt is when the key last authenticated a request
+	LastUsedAt *time.Time
+}
+
+type FormattedAPIKey struct {
+	ID         uint    `json:"id"`
+	Name       string  `json:"name"`
+	Role       string  `json:"role"`
+	Prefix     string  `json:"prefix"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at"`
+	Key        string  `json:"key,omitempty"`
+}
+
+// FormatAsAPIKey creates a simple representation of an API key, the key itself is never included as it is only shown
+// once when it is created
+func (k *APIKey) FormatAsAPIKey() FormattedAPIKey {
+	formatted := FormattedAPIKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		Role:      k.Role,
+		Prefix:    k.Prefix,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.LastUsedAt != nil {
+		lastUsedAt := k.LastUsedAt.Format(time.RFC3339)
+		formatted.LastUsedAt = &lastUsedAt
+	}
+	return formatted
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ErrAuditAppendOnly is returned when anything tries to change or delete an audit entry
+var ErrAuditAppendOnly = errors.New("audit entries can't be changed or deleted")
+
+// AuditEntry records one change to a table or reservation, who made it and what it looked like before and after.
+// Entries are only ever added
+type AuditEntry struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	// Actor is the name of the API key or the subject of the token the change was made with
+	Actor      string `gorm:"size:255;index"`
+	AuthMethod string
+	RequestID  string `gorm:"size:128;index"`
+	Action     string
+	Resource   string // table or reservation
+	// Guest and TableNumber are copied out of the snapshots so entries can be searched by them
+	Guest       string `gorm:"size:255;index"`
+	TableNumber *int   `gorm:"index"`
+	Before      string `gorm:"type:text"` // JSON snapshot, empty for creations
+	After       string `gorm:"type:text"` // JSON snapshot, empty for deletions
+}
+
+type FormattedAuditEntry struct {
+	ID          uint            `json:"id"`
+	Time        string          `json:"time"`
+	Actor       string          `json:"actor"`
+	AuthMethod  string          `json:"auth_method,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	Action      string          `json:"action"`
+	Resource    string          `json:"resource"`
+	Guest       string          `json:"guest,omitempty"`
+	TableNumber *int            `json:"table,omitempty"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+}
+
+// BeforeUpdate stops audit entries from being changed
+func (e *AuditEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// BeforeDelete stops audit entries from being deleted
+func (e *AuditEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// FormatAsAuditEntry creates a simple representation of an audit entry with its snapshots decoded
+func (e *AuditEntry) FormatAsAuditEntry() FormattedAuditEntry {
+	return FormattedAuditEntry{
+		ID:          e.ID,
+		Time:        e.CreatedAt.Format(time.RFC3339Nano),
+		Actor:       e.Actor,
+		AuthMethod:  e.AuthMethod,
+		RequestID:   e.RequestID,
+		Action:      e.Action,
+		Resource:    e.Resource,
+		Guest:       e.Guest,
+		TableNumber: e.TableNumber,
+		Before:      rawJSON(e.Before),
+		After:       rawJSON(e.After),
+	}
+}
+
+// rawJSON passes a stored JSON snapshot through to a response as is, empty snapshots become null
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyKey remembers the first response to a request sent with an Idempotency-Key header so that retries of
+// the same request get the same response instead of being run again
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primarykey"`
+	Key         string `gorm:"size:255;uniqueIndex"`
+	Principal   string `gorm:"size:255"` // who sent the first request, the response is only replayed to them
+	Method      string
+	Path        string `gorm:"type:text"`
+	RequestHash string
+	// Completed is false while the first request is still being handled
+	Completed  bool
+	StatusCode int
+	Header     string `gorm:"type:text"` // JSON encoded response headers
+	Body       []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time `gorm:"index"`
+}
@@ -12,6 +12,8 @@ type Reservation struct {
 	TableID            int
 	Table              Table
 	ArrivalTime        *time.Time
+	// Version goes up by one on every change, it is the reservation's ETag
+	Version uint `gorm:"not null;default:1"`
 }
 
 type FormattedReservation struct {
@@ -26,6 +28,14 @@ type FormattedGuestArrival struct {
 	TimeArrived        string `json:"time_arrived"`
 }
 
+// BeforeCreate starts every new reservation at version 1
+func (r *Reservation) BeforeCreate(tx *gorm.DB) error {
+	if r.Version == 0 {
+		r.Version = 1
+	}
+	return nil
+}
+
 // FormatAsReservation creates a simple string representation of a reservation without arrival time as only a checked
 // in guest has one
 func (r *Reservation) FormatAsReservation() FormattedReservation {
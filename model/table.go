@@ -6,4 +6,36 @@ type Table struct {
 	gorm.Model
 	Number int
 	Seats  int
+	// Version goes up by one on every change, it is the table's ETag
+	Version uint `gorm:"not null;default:1"`
+}
+
+type FormattedTable struct {
+	Number int `json:"number"`
+	Seats  int `json:"seats"`
+}
+
+// BeforeCreate starts every new table at version 1
+func (t *Table) BeforeCreate(tx *gorm.DB) error {
+	if t.Version == 0 {
+		t.Version = 1
+	}
+	return nil
+}
+
+// FormatAsTable creates a simple representation of a table without any of the database fields
+func (t *Table) FormatAsTable() FormattedTable {
+	return FormattedTable{
+		Number: t.Number,
+		Seats:  t.Seats,
+	}
+}
+
+// TableSummary is a table along with how many of its seats are reserved, taken by guests that have arrived and free
+type TableSummary struct {
+	Number        int `json:"number"`
+	Seats         int `json:"seats"`
+	ReservedSeats int `json:"reserved_seats"`
+	ArrivedSeats  int `json:"arrived_seats"`
+	FreeSeats     int `json:"free_seats"`
 }
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type WebhookSubscription struct {
+	gorm.Model
+	URL    string
+	Secret string
+	Events string // Comma separated list of event types, empty means every event
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookSubscriptionID uint
+	DeliveryID            string
+	Event                 string
+	Payload               string `gorm:"type:text"`
+	Attempt               int
+	StatusCode            int
+	Error                 string `gorm:"type:text"`
+	Succeeded             bool
+}
+
+type FormattedWebhookSubscription struct {
+	ID     uint     `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+type FormattedWebhookDelivery struct {
+	DeliveryID  string `json:"delivery_id"`
+	Event       string `json:"event"`
+	Attempt     int    `json:"attempt"`
+	StatusCode  int    `json:"status_code"`
+	Error       string `json:"error,omitempty"`
+	Succeeded   bool   `json:"succeeded"`
+	AttemptedAt string `json:"attempted_at"`
+}
+
+// EventList splits the stored events into a list, an empty list means the subscription receives every event
+func (s *WebhookSubscription) EventList() []string {
+	if s.Events == "" {
+		return []string{}
+	}
+	return strings.Split(s.Events, ",")
+}
+
+// Wants checks if the subscription is interested in a given event type
+func (s *WebhookSubscription) Wants(event string) bool {
+	events := s.EventList()
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatAsWebhookSubscription creates a simple representation of a subscription, the secret is never included as it
+// is only shown once when the subscription is created
+func (s *WebhookSubscription) FormatAsWebhookSubscription() FormattedWebhookSubscription {
+	return FormattedWebhookSubscription{
+		ID:     s.ID,
+		URL:    s.URL,
+		Events: s.EventList(),
+	}
+}
+
+// FormatAsWebhookDelivery creates a simple representation of a single delivery attempt
+func (d *WebhookDelivery) FormatAsWebhookDelivery() FormattedWebhookDelivery {
+	return FormattedWebhookDelivery{
+		DeliveryID:  d.DeliveryID,
+		Event:       d.Event,
+		Attempt:     d.Attempt,
+		StatusCode:  d.StatusCode,
+		Error:       d.Error,
+		Succeeded:   d.Succeeded,
+		AttemptedAt: d.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,62 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"net/http"
+)
+
+var (
+	// Spec is the OpenAPI document describing every route the server registers
+	//go:embed openapi.yaml
+	Spec []byte
+
+	//go:embed docs.html
+	docs []byte
+
+	// The Swagger UI files are served by the server itself rather than a CDN, they are swagger-ui-dist 5.29.1
+	//go:embed swagger-ui
+	assets embed.FS
+	// HandleDocsAsset serves the scripts and styles the documentation page loads from /docs/{file}
+	HandleDocsAsset http.Handler
+
+	specJSON []byte
+)
+
+func init() {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(Spec, &doc); err != nil {
+		panic(err)
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	specJSON = out
+
+	sub, err := fs.Sub(assets, "swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+	HandleDocsAsset = http.StripPrefix("/docs/", http.FileServer(http.FS(sub)))
+}
+
+// HandleSpecYAML serves the OpenAPI document as it is written
+func HandleSpecYAML(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(Spec)
+}
+
+// HandleSpecJSON serves the OpenAPI document converted to JSON for tools that don't read YAML
+func HandleSpecJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(specJSON)
+}
+
+// HandleDocs serves a Swagger UI page that renders the OpenAPI document
+func HandleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(docs)
+}
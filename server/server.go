@@ -1,11 +1,26 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/ctompkinson/guest-list/auth"
 	"github.com/ctompkinson/guest-list/database"
+	"github.com/ctompkinson/guest-list/fixtures"
+	"github.com/ctompkinson/guest-list/grpcapi"
 	"github.com/ctompkinson/guest-list/handlers"
+	"github.com/ctompkinson/guest-list/idempotency"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/openapi"
+	"github.com/ctompkinson/guest-list/service"
+	"github.com/ctompkinson/guest-list/tracing"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -13,38 +28,174 @@ type Server struct {
 	router *mux.Router
 }
 
-func Start() {
-	router := mux.NewRouter()
+// NewRouter creates a router with every route the API serves along with its documentation
+func NewRouter() *mux.Router {
+	router := handlers.NewRouter()
 
-	router.HandleFunc("/table/{tableNumber}", handlers.HandleCreateTable).Methods("POST")
-	router.HandleFunc("/table/{tableNumber}", handlers.HandleDeleteTable).Methods("DELETE")
-	router.HandleFunc("/table/{tableNumber}", handlers.HandleGetTable).Methods("GET")
+	// The documentation is public
+	router.HandleFunc("/openapi.yaml", openapi.HandleSpecYAML).Methods("GET")
+	router.HandleFunc("/openapi.json", openapi.HandleSpecJSON).Methods("GET")
+	router.HandleFunc("/docs", openapi.HandleDocs).Methods("GET")
+	router.Handle("/docs/{file}", openapi.HandleDocsAsset).Methods("GET")
 
-	router.HandleFunc("/guest_list/{name}", handlers.HandleCreateReservation).Methods("POST")
-	router.HandleFunc("/guest_list/{name}", handlers.HandleDeleteReservation).Methods("DELETE")
-	router.HandleFunc("/guest_list", handlers.HandleGetReservations).Methods("GET")
+	return router
+}
 
-	router.HandleFunc("/guests", handlers.HandleListGuests).Methods("GET")
-	router.HandleFunc("/guest/{name}", handlers.HandleGuestArrival).Methods("PUT")
-	// We reuse delete reservation because its effectively the same thing
-	router.HandleFunc("/guest/{name}", handlers.HandleDeleteReservation).Methods("DELETE")
+// Options change how the server starts
+type Options struct {
+	// Fixture is loaded once the database is connected, as long as it doesn't have any tables yet
+	Fixture *fixtures.Fixture
+}
 
-	router.HandleFunc("/seats_empty", handlers.HandleGetEmptySeats).Methods("GET")
-	router.HandleFunc("/invitation/{name}", handlers.HandleCreateInvitation).Methods("GET")
+func Start(opts Options) {
+	// GUESTLIST_LOG_FORMAT is json or text, GUESTLIST_LOG_LEVEL is any logrus level such as debug or info
+	if err := logging.ConfigureFromEnv(); err != nil {
+		panic(err)
+	}
+	log := logging.Log
 
-	// Start the database and and make it available to the API
-	if err := database.Init(); err != nil {
+	// GUESTLIST_TRACING_EXPORTER is none, stdout or otlp, see the tracing package for how the collector is configured
+	shutdownTracing, err := tracing.ConfigureFromEnv(context.Background())
+	if err != nil {
 		panic(err)
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.WithError(err).Error("failed to flush traces")
+		}
+	}()
+
+	router := NewRouter()
 
+	// GUESTLIST_BOOTSTRAP_API_KEY is stored as an admin key once the database is connected so a fresh install can
+	// create the rest
+	bootstrapKey := os.Getenv("GUESTLIST_BOOTSTRAP_API_KEY")
+	if bootstrapKey != "" && !strings.HasPrefix(bootstrapKey, auth.KeyPrefix) {
+		panic(fmt.Errorf("GUESTLIST_BOOTSTRAP_API_KEY must start with %s", auth.KeyPrefix))
+	}
+	// Tokens from a single sign on provider are accepted alongside API keys when a JWKS is configured
+	if cfg, ok, err := auth.JWTConfigFromEnv(); err != nil {
+		panic(err)
+	} else if ok {
+		validator, err := auth.NewJWTValidator(cfg)
+		if err != nil {
+			panic(err)
+		}
+		auth.JWT = validator
+	}
+	if os.Getenv("GUESTLIST_AUTH_DISABLED") == "true" {
+		log.Warn("authentication is disabled, every request is treated as an admin")
+		auth.Disabled = true
+	}
+
+	// Webhooks to loopback, private and link-local addresses are refused unless GUESTLIST_WEBHOOK_ALLOW_PRIVATE is true,
+	// which is only meant for local development
+	if os.Getenv("GUESTLIST_WEBHOOK_ALLOW_PRIVATE") == "true" {
+		log.Warn("webhooks can be sent to private addresses")
+		webhooks.AllowPrivate = true
+	}
+	// Saved responses to idempotent requests are kept for GUESTLIST_IDEMPOTENCY_WINDOW, e.g. 24h
+	if window := os.Getenv("GUESTLIST_IDEMPOTENCY_WINDOW"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			panic(fmt.Errorf("invalid GUESTLIST_IDEMPOTENCY_WINDOW: %w", err))
+		}
+		idempotency.Window = d
+	}
+	// Deleted tables and reservations can be restored for GUESTLIST_DELETED_RETENTION, e.g. 720h, before being purged
+	retention := 30 * 24 * time.Hour
+	if v := os.Getenv("GUESTLIST_DELETED_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			panic(fmt.Errorf("invalid GUESTLIST_DELETED_RETENTION: %w", err))
+		}
+		retention = d
+	}
+	// Connecting to the database is retried for GUESTLIST_DB_CONNECT_TIMEOUT, e.g. 5m, 0 retries forever
+	connectTimeout := 5 * time.Minute
+	if v := os.Getenv("GUESTLIST_DB_CONNECT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			panic(fmt.Errorf("invalid GUESTLIST_DB_CONNECT_TIMEOUT: %w", err))
+		}
+		connectTimeout = d
+	}
+
+	// The server starts before the database is connected so it can answer health checks while it waits, everything
+	// else gets a 503 until then
 	srv := &http.Server{
 		Handler:      router,
 		Addr:         "0.0.0.0:8080", // TODO: Make the port and address configurable
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  15 * time.Second,
 	}
-	fmt.Println("Starting Server")
-	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-		fmt.Println("error starting server:", err)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		log.WithField("addr", srv.Addr).Info("starting server")
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.WithError(err).Fatal("error starting server")
+		}
+	}()
+
+	// The gRPC API for internal integrations listens on GUESTLIST_GRPC_ADDR, 0.0.0.0:9090 unless it is set, and is
+	// turned off by setting it to off
+	grpcAddr := os.Getenv("GUESTLIST_GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = "0.0.0.0:9090"
+	}
+	if grpcAddr != "off" {
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.WithError(err).Fatal("error starting gRPC server")
+		}
+		go func() {
+			log.WithField("addr", grpcAddr).Info("starting gRPC server")
+			if err := grpcapi.NewServer().Serve(lis); err != nil {
+				log.WithError(err).Fatal("error serving gRPC")
+			}
+		}()
+	}
+
+	// Start the database and and make it available to the API
+	if err := database.Connect(connectTimeout, 30*time.Second); err != nil {
+		log.WithError(err).Fatal("giving up on the database")
+	}
+	log.Info("connected to the database")
+	if bootstrapKey != "" {
+		if err := auth.EnsureKey(database.Get(), "bootstrap", auth.RoleAdmin, bootstrapKey); err != nil {
+			log.WithError(err).Fatal("failed to store the bootstrap API key")
+		}
 	}
+	if opts.Fixture != nil {
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{Name: "fixture", Method: "system"})
+		err := fixtures.LoadIfEmpty(database.Get().WithContext(ctx), *opts.Fixture)
+		if errors.Is(err, fixtures.ErrNotEmpty) {
+			log.Info("the database already has tables, the fixture was not loaded")
+		} else if err != nil {
+			log.WithError(err).Fatal("failed to load the fixture")
+		} else {
+			log.Info("loaded the fixture")
+		}
+	}
+	// The seat gauges are refreshed after every change, this sets them for what was already there
+	service.RefreshMetrics(database.Get())
+
+	go func() {
+		// The purge is recorded in the audit log as the purge job
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{Name: "purge job", Method: "system"})
+		for range time.Tick(time.Hour) {
+			if _, err := idempotency.Purge(database.Get(), time.Now()); err != nil {
+				log.WithError(err).Error("failed to purge idempotency keys")
+			}
+			tables, reservations, err := service.PurgeDeleted(database.Get().WithContext(ctx), time.Now().Add(-retention))
+			if err != nil {
+				log.WithError(err).Error("failed to purge deleted tables and reservations")
+			} else if tables > 0 || reservations > 0 {
+				log.WithFields(logrus.Fields{"tables": tables, "reservations": reservations}).Info("purged deleted tables and reservations")
+			}
+		}
+	}()
+
+	<-stopped
 }
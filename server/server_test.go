@@ -0,0 +1,96 @@
+package server
+
+import (
+	"github.com/ctompkinson/guest-list/openapi"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPICoversRoutes fails if a route is registered without being described in the OpenAPI document, or if the
+// document describes a route that doesn't exist
+func TestOpenAPICoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	require.NoError(t, yaml.Unmarshal(openapi.Spec, &spec))
+
+	registered := map[string]bool{}
+	err := NewRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, m := range methods {
+			m = strings.ToLower(m)
+			registered[m+" "+path] = true
+			_, ok := spec.Paths[path][m]
+			assert.True(t, ok, "%s %s is not described in openapi.yaml", strings.ToUpper(m), path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	for path, operations := range spec.Paths {
+		for m := range operations {
+			if m == "parameters" {
+				continue
+			}
+			assert.True(t, registered[m+" "+path], "%s %s is in openapi.yaml but not registered", strings.ToUpper(m), path)
+		}
+	}
+}
+
+// TestRoutesRequireAuthentication fails if a route, other than the documentation and health checks, can be used without
+// credentials
+func TestRoutesRequireAuthentication(t *testing.T) {
+	public := map[string]bool{"/openapi.yaml": true, "/openapi.json": true, "/docs": true, "/docs/{file}": true, "/health/live": true, "/health/ready": true}
+	router := NewRouter()
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil || public[path] {
+			return nil
+		}
+		url := regexp.MustCompile(`\{[^}]+\}`).ReplaceAllString(path, "1")
+		for _, m := range methods {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(m, url, nil))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, "%s %s can be used without credentials", m, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestDocsAssets fails if the documentation page loads anything the server doesn't serve itself
+func TestDocsAssets(t *testing.T) {
+	router := NewRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/docs", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "https://")
+
+	files := regexp.MustCompile(`(?:src|href)="(/docs/[^"]+)"`).FindAllStringSubmatch(rr.Body.String(), -1)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", file[1], nil))
+		assert.Equal(t, http.StatusOK, rr.Code, file[1])
+	}
+}
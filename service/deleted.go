@@ -0,0 +1,173 @@
+package service
+
+import (
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/tracing"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"gorm.io/gorm"
+	"time"
+)
+
+// unscopedTable loads a reservation's table even if it has been deleted
+func unscopedTable(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// ListDeletedTables gets every table that has been deleted but not purged yet, most recently deleted first
+func ListDeletedTables(db *gorm.DB) ([]model.Table, error) {
+	tables := []model.Table{}
+	err := db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC, id DESC").Find(&tables).Error
+	if err != nil {
+		return nil, apierror.Internal(err, "failed to load deleted tables")
+	}
+	return tables, nil
+}
+
+// ListDeletedReservations gets every reservation that has been deleted but not purged yet, most recently deleted
+// first, along with their tables
+func ListDeletedReservations(db *gorm.DB) ([]model.Reservation, error) {
+	reservations := []model.Reservation{}
+	err := db.Unscoped().Preload("Table", unscopedTable).
+		Where("deleted_at IS NOT NULL").Order("deleted_at DESC, id DESC").Find(&reservations).Error
+	if err != nil {
+		return nil, apierror.Internal(err, "failed to load deleted reservations")
+	}
+	return reservations, nil
+}
+
+// GetDeletedTable finds the most recently deleted table with a number, there can be more than one if the number has
+// been reused
+func GetDeletedTable(db *gorm.DB, number int) (model.Table, error) {
+	var table model.Table
+	err := db.Unscoped().Where("number = ? AND deleted_at IS NOT NULL", number).Order("deleted_at DESC, id DESC").First(&table).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return table, apierror.NotFound("there is no deleted table %d", number)
+		}
+		return table, apierror.Internal(err, "failed to get deleted table")
+	}
+	tracing.Table(db.Statement.Context, table.Number)
+	return table, nil
+}
+
+// GetDeletedReservation finds a guest's deleted reservation along with its table
+func GetDeletedReservation(db *gorm.DB, guest string) (model.Reservation, error) {
+	var reservation model.Reservation
+	err := db.Unscoped().Preload("Table", unscopedTable).Where("guest = ? AND deleted_at IS NOT NULL", guest).First(&reservation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reservation, apierror.NotFound("guest does not have a deleted reservation")
+		}
+		return reservation, apierror.Internal(err, "failed to lookup deleted reservation")
+	}
+	tracing.Reservation(db.Statement.Context, reservation)
+	return reservation, nil
+}
+
+// RestoreTable brings back a deleted table, as long as no other table has been given its number since
+func RestoreTable(db *gorm.DB, table model.Table) (model.Table, error) {
+	before := table
+	err := Transaction(db, func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&model.Table{}).Where("number = ?", table.Number).Count(&existing).Error; err != nil {
+			return apierror.Internal(err, "failed to check if table exists")
+		}
+		if existing != 0 {
+			return apierror.Conflict("table %d has been created again since it was deleted", table.Number)
+		}
+
+		values := map[string]interface{}{"deleted_at": nil}
+		if err := updateVersioned(tx.Unscoped(), &model.Table{}, table.ID, table.Version, values, "table"); err != nil {
+			return err
+		}
+		table.DeletedAt = gorm.DeletedAt{}
+		table.Version++
+		dispatch(tx, webhooks.EventTableRestored, table.FormatAsTable())
+		return auditTable(tx, audit.ActionUndelete, &before, &table)
+	})
+	if err != nil {
+		return before, err
+	}
+	return table, nil
+}
+
+// RestoreReservation brings back a deleted reservation. Its table must not be deleted and must still have room for
+// the whole party, as its seats may have been given to someone else since
+func RestoreReservation(db *gorm.DB, reservation model.Reservation) (model.Reservation, error) {
+	if reservation.Table.DeletedAt.Valid {
+		return reservation, apierror.Conflict("table %d has been deleted, restore it first", reservation.Table.Number)
+	}
+
+	before := reservation
+	err := Transaction(db, func(tx *gorm.DB) error {
+		table, err := lockTable(tx, reservation.Table)
+		if err != nil {
+			return err
+		}
+		enoughSeats, err := seatsAvailable(tx, table, reservation.AccompanyingGuests+1)
+		if err != nil {
+			return err
+		}
+		if !enoughSeats {
+			return apierror.CapacityExceeded("table %d no longer has %d free seats", reservation.Table.Number, reservation.AccompanyingGuests+1)
+		}
+
+		values := map[string]interface{}{"deleted_at": nil}
+		if err := updateVersioned(tx.Unscoped(), &model.Reservation{}, reservation.ID, reservation.Version, values, "reservation"); err != nil {
+			return err
+		}
+		reservation.DeletedAt = gorm.DeletedAt{}
+		reservation.Version++
+		dispatch(tx, webhooks.EventReservationRestored, reservation.FormatAsReservation())
+		return auditReservation(tx, audit.ActionUndelete, &before, &reservation)
+	})
+	if err != nil {
+		return before, err
+	}
+	return reservation, nil
+}
+
+// PurgeDeleted permanently removes the tables and reservations that were deleted before the given time, returning
+// how many of each were removed. Tables are kept while any reservation, deleted or not, still points at them
+func PurgeDeleted(db *gorm.DB, before time.Time) (tables, reservations int64, err error) {
+	err = Transaction(db, func(tx *gorm.DB) error {
+		var oldReservations []model.Reservation
+		err := tx.Unscoped().Preload("Table", unscopedTable).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Find(&oldReservations).Error
+		if err != nil {
+			return apierror.Internal(err, "failed to load deleted reservations")
+		}
+		for i := range oldReservations {
+			if err := tx.Unscoped().Delete(&oldReservations[i]).Error; err != nil {
+				return apierror.Internal(err, "failed to purge reservation")
+			}
+			if err := auditReservation(tx, audit.ActionPurge, &oldReservations[i], nil); err != nil {
+				return err
+			}
+		}
+
+		var oldTables []model.Table
+		err = tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM reservations WHERE reservations.table_id = tables.id)").
+			Find(&oldTables).Error
+		if err != nil {
+			return apierror.Internal(err, "failed to load deleted tables")
+		}
+		for i := range oldTables {
+			if err := tx.Unscoped().Delete(&oldTables[i]).Error; err != nil {
+				return apierror.Internal(err, "failed to purge table")
+			}
+			if err := auditTable(tx, audit.ActionPurge, &oldTables[i], nil); err != nil {
+				return err
+			}
+		}
+
+		tables, reservations = int64(len(oldTables)), int64(len(oldReservations))
+		return nil
+	})
+	return tables, reservations, err
+}
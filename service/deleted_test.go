@@ -0,0 +1,108 @@
+package service
+
+import (
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	table, err := CreateTable(db, NewTable{Number: 1, Seats: 2})
+	require.NoError(t, err)
+	bob, err := CreateReservation(db, NewReservation{Guest: "bob", TableNumber: 1, AccompanyingGuests: 1})
+	require.NoError(t, err)
+	require.NoError(t, DeleteReservation(db, bob))
+
+	// Bob's seats have been taken since, so he doesn't fit any more
+	alice, err := CreateReservation(db, NewReservation{Guest: "alice", TableNumber: 1})
+	require.NoError(t, err)
+	deleted, err := GetDeletedReservation(db, "bob")
+	require.NoError(t, err)
+	_, err = RestoreReservation(db, deleted)
+	assert.Equal(t, apierror.KindCapacityExceeded, apierror.KindOf(err))
+
+	require.NoError(t, DeleteReservation(db, alice))
+	restored, err := RestoreReservation(db, deleted)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	_, err = GetReservation(db, "bob")
+	require.NoError(t, err)
+
+	// A table can only come back if its number is still free
+	table, err = GetTable(db, table.Number)
+	require.NoError(t, err)
+	require.NoError(t, DeleteReservation(db, restored))
+	require.NoError(t, DeleteTable(db, table))
+	_, err = CreateTable(db, NewTable{Number: 1, Seats: 8})
+	require.NoError(t, err)
+
+	deletedTable, err := GetDeletedTable(db, 1)
+	require.NoError(t, err)
+	_, err = RestoreTable(db, deletedTable)
+	assert.Equal(t, apierror.KindConflict, apierror.KindOf(err))
+
+	_, err = GetDeletedTable(db, 2)
+	assert.Equal(t, apierror.KindNotFound, apierror.KindOf(err))
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	keep := model.Table{Number: 1, Seats: 4}
+	db.Create(&keep)
+	old := model.Table{Number: 2, Seats: 4}
+	db.Create(&old)
+	db.Create(&model.Reservation{Guest: "bob", Table: keep})
+	db.Create(&model.Reservation{Guest: "alice", Table: keep})
+
+	bob, err := GetReservation(db, "bob")
+	require.NoError(t, err)
+	require.NoError(t, DeleteReservation(db, bob))
+	require.NoError(t, DeleteTable(db, old))
+
+	// Nothing was deleted before an hour ago
+	tables, reservations, err := PurgeDeleted(db, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), tables)
+	assert.Equal(t, int64(0), reservations)
+
+	tables, reservations, err = PurgeDeleted(db, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), tables)
+	assert.Equal(t, int64(1), reservations)
+
+	var count int64
+	db.Unscoped().Model(&model.Reservation{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.Unscoped().Model(&model.Table{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.Model(&model.AuditEntry{}).Where("action = ?", "purge").Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCreateReservation_AfterDelete(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	db.Create(&model.Table{Number: 1, Seats: 4})
+
+	bob, err := CreateReservation(db, NewReservation{Guest: "bob", TableNumber: 1, AccompanyingGuests: 1})
+	require.NoError(t, err)
+	require.NoError(t, DeleteReservation(db, bob))
+
+	// The deleted reservation keeps the name, it has to be restored rather than replaced
+	_, err = CreateReservation(db, NewReservation{Guest: "bob", TableNumber: 1})
+	assert.Equal(t, apierror.KindConflict, apierror.KindOf(err))
+
+	deleted, err := GetDeletedReservation(db, "bob")
+	require.NoError(t, err)
+	restored, err := RestoreReservation(db, deleted)
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, restored.ID)
+	assert.Equal(t, 1, restored.AccompanyingGuests)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"github.com/ctompkinson/guest-list/model"
+	"sync"
+	"time"
+)
+
+// ArrivalEventType is what happened to a guest's arrival
+type ArrivalEventType string
+
+const (
+	// Arrived is sent when a guest checks in
+	Arrived ArrivalEventType = "arrived"
+	// CheckInUndone is sent when a guest's check in is undone, their reservation is kept
+	CheckInUndone ArrivalEventType = "check_in_undone"
+)
+
+// ArrivalEvent is sent to subscribers once a check in, or undoing one, has committed
+type ArrivalEvent struct {
+	Type        ArrivalEventType
+	Reservation model.Reservation
+	Time        time.Time
+}
+
+// arrivalBroker fans arrival events out to every subscriber in this process
+type arrivalBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan ArrivalEvent]struct{}
+}
+
+var arrivals = &arrivalBroker{subscribers: map[chan ArrivalEvent]struct{}{}}
+
+// SubscribeArrivals returns a channel that receives every arrival event from now on, along with a function to stop
+// receiving them. Subscribers that fall more than buffer events behind have their channel closed rather than holding up
+// check ins, they should subscribe again and catch up with ListArrivals
+func SubscribeArrivals(buffer int) (<-chan ArrivalEvent, func()) {
+	ch := make(chan ArrivalEvent, buffer)
+	arrivals.mu.Lock()
+	arrivals.subscribers[ch] = struct{}{}
+	arrivals.mu.Unlock()
+
+	return ch, func() {
+		arrivals.mu.Lock()
+		defer arrivals.mu.Unlock()
+		if _, ok := arrivals.subscribers[ch]; ok {
+			delete(arrivals.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *arrivalBroker) publish(e ArrivalEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
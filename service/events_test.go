@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"testing"
+)
+
+// Not parallel, the arrival subscribers are shared by every test
+func TestSubscribeArrivals(t *testing.T) {
+	db := databasetest.New(t)
+	table := model.Table{Number: 1, Seats: 4}
+	db.Create(&table)
+	db.Create(&model.Reservation{Guest: "bob", Table: table})
+	bob, err := GetReservation(db, "bob")
+	require.NoError(t, err)
+
+	events, stop := SubscribeArrivals(1)
+	defer stop()
+
+	// Nothing is sent for a check in that was rolled back
+	failed := errors.New("failed")
+	err = Transaction(db, func(tx *gorm.DB) error {
+		if _, err := CheckIn(tx, bob, 0); err != nil {
+			return err
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Empty(t, events)
+
+	arrived, err := CheckIn(db, bob, 0)
+	require.NoError(t, err)
+	e := <-events
+	assert.Equal(t, Arrived, e.Type)
+	assert.Equal(t, "bob", e.Reservation.Guest)
+	assert.Equal(t, arrived.Version, e.Reservation.Version)
+
+	// A subscriber that falls behind is dropped rather than holding up check ins
+	undone, err := UndoCheckIn(db, arrived)
+	require.NoError(t, err)
+	_, err = CheckIn(db, undone, 0)
+	require.NoError(t, err)
+	e = <-events
+	assert.Equal(t, CheckInUndone, e.Type)
+	_, open := <-events
+	assert.False(t, open)
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/tracing"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"gorm.io/gorm"
+	"time"
+)
+
+// NewReservation is a reservation to create, covering the guest and everyone they are bringing
+type NewReservation struct {
+	Guest              string
+	TableNumber        int
+	AccompanyingGuests int
+}
+
+// GetReservation finds a guest's reservation along with its table
+func GetReservation(db *gorm.DB, guest string) (model.Reservation, error) {
+	var reservation model.Reservation
+	if guest == "" {
+		return reservation, apierror.BadRequest("guest name is required")
+	}
+	tracing.Guest(db.Statement.Context, guest)
+	if err := db.Preload("Table").Where("guest = ?", guest).First(&reservation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reservation, apierror.NotFound("guest does not have a reservation")
+		}
+		return reservation, apierror.Internal(err, "failed to lookup reservation")
+	}
+	tracing.Reservation(db.Statement.Context, reservation)
+	return reservation, nil
+}
+
+// ListReservations gets every reservation along with its table, ordered by guest name
+func ListReservations(db *gorm.DB) ([]model.Reservation, error) {
+	reservations := []model.Reservation{}
+	if err := db.Preload("Table").Order("guest").Find(&reservations).Error; err != nil {
+		return nil, apierror.Internal(err, "failed to load reservations")
+	}
+	return reservations, nil
+}
+
+// ListArrivals gets the reservation of every guest that has arrived along with its table, in the order they arrived
+func ListArrivals(db *gorm.DB) ([]model.Reservation, error) {
+	reservations := []model.Reservation{}
+	err := db.Preload("Table").Where("arrival_time IS NOT NULL").Order("arrival_time, id").Find(&reservations).Error
+	if err != nil {
+		return nil, apierror.Internal(err, "failed to load arrivals")
+	}
+	return reservations, nil
+}
+
+// ValidateReservation checks a new reservation's guest name and accompanying guests, without looking at what is already
+// in the database
+func ValidateReservation(in NewReservation) error {
+	if in.Guest == "" {
+		return apierror.Validation("guest name is required")
+	}
+	if in.AccompanyingGuests < 0 {
+		return apierror.Validation("accompanying guests can't be negative")
+	}
+	return nil
+}
+
+// CreateReservation reserves seats on a table for a guest and everyone they are bringing, a guest can only have one
+// reservation
+func CreateReservation(db *gorm.DB, in NewReservation) (model.Reservation, error) {
+	var reservation model.Reservation
+	if err := ValidateReservation(in); err != nil {
+		return reservation, err
+	}
+	tracing.Guest(db.Statement.Context, in.Guest)
+
+	table, err := GetTable(db, in.TableNumber)
+	if err != nil {
+		return reservation, err
+	}
+
+	reservation = model.Reservation{
+		Guest:              in.Guest,
+		AccompanyingGuests: in.AccompanyingGuests,
+		Table:              table,
+	}
+	err = Transaction(db, func(tx *gorm.DB) error {
+		// Lock the table before checking anything, so two reservations can't both be given its last seats
+		table, err := lockTable(tx, table)
+		if err != nil {
+			return err
+		}
+		reservation.Table = table
+
+		if err := checkGuestName(tx, in.Guest); err != nil {
+			return err
+		}
+
+		// Check for all our guests, plus the main guest
+		enoughSeats, err := seatsAvailable(tx, table, in.AccompanyingGuests+1)
+		if err != nil {
+			return err
+		}
+		if !enoughSeats {
+			return apierror.CapacityExceeded("not enough seats available on selected table")
+		}
+
+		// Now we can finally create the reservation
+		if err := tx.Create(&reservation).Error; err != nil {
+			return apierror.Internal(err, "failed to create reservations")
+		}
+		dispatch(tx, webhooks.EventReservationCreated, reservation.FormatAsReservation())
+		return auditReservation(tx, audit.ActionCreate, nil, &reservation)
+	})
+	if err == nil {
+		tracing.Reservation(db.Statement.Context, reservation)
+	}
+	return reservation, err
+}
+
+// checkGuestName makes sure a guest doesn't have a reservation already. Deleted reservations count too, guest names
+// are unique among them so a guest's deleted reservation has to be restored, or purged, before they can have another
+func checkGuestName(db *gorm.DB, guest string) error {
+	var existing model.Reservation
+	result := db.Unscoped().Where("guest = ?", guest).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return apierror.Internal(result.Error, "failed to query for existing guest reservations")
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	if existing.DeletedAt.Valid {
+		return apierror.Conflict("the guest has a deleted reservation, restore it rather than making a new one")
+	}
+	return apierror.Conflict("the guest already has a reservation")
+}
+
+// DeleteReservation cancels a reservation, it is only soft deleted so it can be restored
+func DeleteReservation(db *gorm.DB, reservation model.Reservation) error {
+	return Transaction(db, func(tx *gorm.DB) error {
+		if err := deleteVersioned(tx, &model.Reservation{}, reservation.ID, reservation.Version, "reservation"); err != nil {
+			return err
+		}
+		dispatch(tx, webhooks.EventReservationDeleted, reservation.FormatAsReservation())
+		return auditReservation(tx, audit.ActionDelete, &reservation, nil)
+	})
+}
+
+// CheckIn marks a guest as arrived, they may turn up with a different amount of guests as long as the table has room.
+// Checking in a guest that has already arrived keeps their arrival time and only changes their accompanying guests, so
+// retrying a check in doesn't tell subscribers they arrived twice
+func CheckIn(db *gorm.DB, reservation model.Reservation, accompanyingGuests int) (model.Reservation, error) {
+	if accompanyingGuests < 0 {
+		return reservation, apierror.Validation("accompanying guests can't be negative")
+	}
+	alreadyArrived := reservation.ArrivalTime != nil
+	if alreadyArrived && reservation.AccompanyingGuests == accompanyingGuests {
+		return reservation, nil
+	}
+
+	before := reservation
+	err := Transaction(db, func(tx *gorm.DB) error {
+		// Does our reservation have a different amount of seats?
+		if reservation.AccompanyingGuests != accompanyingGuests {
+			// Lock the table before checking it, so nobody else can be given the seats we are about to take
+			table, err := lockTable(tx, reservation.Table)
+			if err != nil {
+				return err
+			}
+
+			// Were going to check our own table by checking reservations, so we only want to check for new guests (minus overselves)
+			newGuests := accompanyingGuests - reservation.AccompanyingGuests
+
+			enoughSeats, err := seatsAvailable(tx, table, newGuests)
+			if err != nil {
+				return err
+			}
+			if !enoughSeats {
+				return apierror.CapacityExceeded("not enough seats available on selected table")
+			}
+		}
+
+		// We can now update our reservation and add an arrival time
+		now := time.Now()
+		values := map[string]interface{}{"accompanying_guests": accompanyingGuests}
+		if !alreadyArrived {
+			values["arrival_time"] = now
+		}
+		if err := updateVersioned(tx, &model.Reservation{}, reservation.ID, reservation.Version, values, "reservation"); err != nil {
+			return err
+		}
+		reservation.AccompanyingGuests = accompanyingGuests
+		reservation.Version++
+		if alreadyArrived {
+			dispatch(tx, webhooks.EventReservationUpdated, reservation.FormatAsReservation())
+			return auditReservation(tx, audit.ActionUpdate, &before, &reservation)
+		}
+
+		reservation.ArrivalTime = &now
+		dispatch(tx, webhooks.EventGuestArrived, reservation.FormatAsGuestArrival())
+		publishArrival(tx, ArrivalEvent{Type: Arrived, Reservation: reservation, Time: now})
+		return auditReservation(tx, audit.ActionCheckIn, &before, &reservation)
+	})
+	if err != nil {
+		return before, err
+	}
+	return reservation, nil
+}
+
+// UndoCheckIn clears a guest's arrival, keeping their reservation
+func UndoCheckIn(db *gorm.DB, reservation model.Reservation) (model.Reservation, error) {
+	if reservation.ArrivalTime == nil {
+		return reservation, apierror.NotFound("guest has not arrived")
+	}
+	before := reservation
+	err := Transaction(db, func(tx *gorm.DB) error {
+		values := map[string]interface{}{"arrival_time": nil}
+		if err := updateVersioned(tx, &model.Reservation{}, reservation.ID, reservation.Version, values, "reservation"); err != nil {
+			return err
+		}
+		reservation.ArrivalTime = nil
+		reservation.Version++
+		publishArrival(tx, ArrivalEvent{Type: CheckInUndone, Reservation: reservation, Time: time.Now()})
+		return auditReservation(tx, audit.ActionUndoCheckIn, &before, &reservation)
+	})
+	if err != nil {
+		return before, err
+	}
+	return reservation, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCreateReservation(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	table := model.Table{Number: 1, Seats: 4}
+	db.Create(&table)
+	db.Create(&model.Reservation{Guest: "alice", AccompanyingGuests: 1, Table: table})
+
+	cases := []struct {
+		name         string
+		input        NewReservation
+		expectedKind apierror.Kind
+	}{
+		{"tooMany", NewReservation{Guest: "bob", TableNumber: 1, AccompanyingGuests: 2}, apierror.KindCapacityExceeded},
+		{"valid", NewReservation{Guest: "bob", TableNumber: 1, AccompanyingGuests: 1}, ""},
+		{"duplicate", NewReservation{Guest: "bob", TableNumber: 1}, apierror.KindConflict},
+		{"unknownTable", NewReservation{Guest: "carol", TableNumber: 2}, apierror.KindNotFound},
+		{"noName", NewReservation{TableNumber: 1}, apierror.KindValidation},
+		{"negativeGuests", NewReservation{Guest: "carol", TableNumber: 1, AccompanyingGuests: -1}, apierror.KindValidation},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			reservation, err := CreateReservation(db, c.input)
+			if c.expectedKind != "" {
+				require.Error(t, err)
+				assert.Equal(t, c.expectedKind, apierror.KindOf(err))
+				return
+			}
+			require.NoError(t, err)
+
+			found, err := GetReservation(db, c.input.Guest)
+			require.NoError(t, err)
+			assert.Equal(t, reservation.ID, found.ID)
+			assert.Equal(t, c.input.TableNumber, found.Table.Number)
+		})
+	}
+}
+
+func TestCheckIn(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name               string
+		accompanyingGuests int
+		expectedKind       apierror.Kind
+	}{
+		{"asReserved", 1, ""},
+		{"fewer", 0, ""},
+		{"moreWithRoom", 2, ""},
+		{"moreWithoutRoom", 3, apierror.KindCapacityExceeded},
+		{"negative", -1, apierror.KindValidation},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db := databasetest.New(t)
+			table := model.Table{Number: 1, Seats: 4}
+			db.Create(&table)
+			db.Create(&model.Reservation{Guest: "alice", Table: table})
+			db.Create(&model.Reservation{Guest: "bob", AccompanyingGuests: 1, Table: table})
+			reservation, err := GetReservation(db, "bob")
+			require.NoError(t, err)
+
+			arrived, err := CheckIn(db, reservation, c.accompanyingGuests)
+			if c.expectedKind != "" {
+				require.Error(t, err)
+				assert.Equal(t, c.expectedKind, apierror.KindOf(err))
+				assert.Nil(t, arrived.ArrivalTime)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, arrived.ArrivalTime)
+			assert.Equal(t, c.accompanyingGuests, arrived.AccompanyingGuests)
+
+			count, err := CountSeats(db)
+			require.NoError(t, err)
+			assert.Equal(t, SeatCount{Total: 4, Reserved: c.accompanyingGuests + 2, Arrived: c.accompanyingGuests + 1}, count)
+
+			// Checking in again from the copy loaded before the first check in is refused
+			_, err = CheckIn(db, reservation, reservation.AccompanyingGuests)
+			assert.Equal(t, apierror.KindPreconditionFailed, apierror.KindOf(err))
+
+			undone, err := UndoCheckIn(db, arrived)
+			require.NoError(t, err)
+			assert.Nil(t, undone.ArrivalTime)
+			_, err = UndoCheckIn(db, undone)
+			assert.Equal(t, apierror.KindNotFound, apierror.KindOf(err))
+		})
+	}
+}
+
+func TestDeleteReservation(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	table := model.Table{Number: 1, Seats: 2}
+	db.Create(&table)
+	reservation, err := CreateReservation(db, NewReservation{Guest: "bob", TableNumber: 1, AccompanyingGuests: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, DeleteReservation(db, reservation))
+	_, err = GetReservation(db, "bob")
+	assert.Equal(t, apierror.KindNotFound, apierror.KindOf(err))
+
+	// The seats are free again
+	_, err = CreateReservation(db, NewReservation{Guest: "alice", TableNumber: 1, AccompanyingGuests: 1})
+	require.NoError(t, err)
+}
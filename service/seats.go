@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/model"
+	"gorm.io/gorm"
+)
+
+// SeatCount is how many seats are on the party's tables, how many have been reserved and how many are taken by guests
+// that have arrived
+type SeatCount struct {
+	Total    int
+	Reserved int
+	Arrived  int
+}
+
+// CountSeats adds up every seat at the party, reserved seats and seats taken by guests that have arrived
+func CountSeats(db *gorm.DB) (SeatCount, error) {
+	var count SeatCount
+
+	// Get all the tables and count their seats
+	var tables []model.Table
+	if err := db.Find(&tables).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return count, apierror.Internal(err, "failed to load tables")
+	}
+	for _, table := range tables {
+		count.Total = count.Total + table.Seats
+	}
+
+	// Get all reservations and count used seats
+	var reservations []model.Reservation
+	if err := db.Find(&reservations).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return count, apierror.Internal(err, "failed to load reservations")
+	}
+	for _, reservation := range reservations {
+		seats := reservation.AccompanyingGuests + 1 // Add on the primary guest
+		count.Reserved = count.Reserved + seats
+		if reservation.ArrivalTime != nil {
+			count.Arrived = count.Arrived + seats
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,136 @@
+// Package service holds the rules for tables, reservations and arrivals, independent of how they are reached. The HTTP
+// handlers, the importers and any other front end call it with typed inputs and get models back.
+//
+// Every error returned is an *apierror.Error, front ends decide what to do with one from its kind using
+// apierror.KindOf, for example the HTTP API turns a KindCapacityExceeded into a 409
+package service
+
+import (
+	"context"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/metrics"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"gorm.io/gorm"
+	"time"
+)
+
+// queuedEvent is a webhook event, or an arrival for subscribers, held back until the transaction it happened in has
+// committed
+type queuedEvent struct {
+	event   string
+	data    interface{}
+	arrival *ArrivalEvent
+}
+
+type eventQueueKey struct{}
+
+// Transaction runs fn in a transaction, webhook events from inside it are only sent once it has committed so
+// subscribers are never told about changes that were rolled back, and the seat metrics are refreshed after. Inside
+// another transaction the events are left for the outer one to send
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Value(eventQueueKey{}).(*[]queuedEvent); ok {
+		return db.Transaction(fn)
+	}
+
+	queue := &[]queuedEvent{}
+	if err := db.WithContext(context.WithValue(ctx, eventQueueKey{}, queue)).Transaction(fn); err != nil {
+		return err
+	}
+	for _, e := range *queue {
+		if e.arrival != nil {
+			arrivals.publish(*e.arrival)
+			continue
+		}
+		webhooks.Dispatch(db, e.event, e.data)
+	}
+	RefreshMetrics(db)
+	return nil
+}
+
+// dispatch sends a webhook event straight away, or queues it when inside a transaction started by Transaction
+func dispatch(db *gorm.DB, event string, data interface{}) {
+	if !enqueue(db, queuedEvent{event: event, data: data}) {
+		webhooks.Dispatch(db, event, data)
+	}
+}
+
+// publishArrival tells arrival subscribers about a check in straight away, or once the transaction has committed
+func publishArrival(db *gorm.DB, e ArrivalEvent) {
+	if !enqueue(db, queuedEvent{arrival: &e}) {
+		arrivals.publish(e)
+	}
+}
+
+// enqueue adds an event to the queue of the transaction db is part of, returning false when it isn't part of one
+func enqueue(db *gorm.DB, e queuedEvent) bool {
+	if db.Statement.Context == nil {
+		return false
+	}
+	queue, ok := db.Statement.Context.Value(eventQueueKey{}).(*[]queuedEvent)
+	if ok {
+		*queue = append(*queue, e)
+	}
+	return ok
+}
+
+// RefreshMetrics sets the per table seat gauges from the database. It runs after every change that commits, failing to
+// refresh only leaves the gauges out of date so the error is logged rather than returned
+func RefreshMetrics(db *gorm.DB) {
+	summaries, err := ListTables(db)
+	if err != nil {
+		logging.FromContext(db.Statement.Context).WithError(err).Warn("failed to refresh table metrics")
+		return
+	}
+	metrics.SetTables(summaries)
+}
+
+// auditTable and auditReservation add a change to the audit log, they should be called with the same handle the change
+// was made with so the entry is rolled back along with it
+func auditTable(db *gorm.DB, action string, before, after *model.Table) error {
+	if err := audit.Table(db, action, before, after); err != nil {
+		return apierror.Internal(err, "failed to record change to table")
+	}
+	return nil
+}
+
+func auditReservation(db *gorm.DB, action string, before, after *model.Reservation) error {
+	if err := audit.Reservation(db, action, before, after); err != nil {
+		return apierror.Internal(err, "failed to record change to reservation")
+	}
+	return nil
+}
+
+// updateVersioned changes a table or reservation and bumps its version, as long as it is still at the version it was
+// loaded at. If someone else has changed it since then nothing is updated and a precondition failed error is returned
+func updateVersioned(db *gorm.DB, row interface{}, id, version uint, values map[string]interface{}, what string) error {
+	values["version"] = gorm.Expr("version + 1")
+	result := db.Model(row).Where("id = ? AND version = ?", id, version).Updates(values)
+	if result.Error != nil {
+		return apierror.Internal(result.Error, "failed to update %s", what)
+	}
+	if result.RowsAffected == 0 {
+		return apierror.PreconditionFailed("%s has been changed by someone else, reload it and try again", what)
+	}
+	return nil
+}
+
+// deleteVersioned soft deletes a table or reservation as long as it is still at the version it was loaded at, it can
+// be restored until PurgeDeleted removes it for good
+func deleteVersioned(db *gorm.DB, row interface{}, id, version uint, what string) error {
+	values := map[string]interface{}{"deleted_at": time.Now(), "version": gorm.Expr("version + 1")}
+	result := db.Model(row).Where("id = ? AND version = ?", id, version).Updates(values)
+	if result.Error != nil {
+		return apierror.Internal(result.Error, "failed to delete %s", what)
+	}
+	if result.RowsAffected == 0 {
+		return apierror.PreconditionFailed("%s has been changed by someone else, reload it and try again", what)
+	}
+	return nil
+}
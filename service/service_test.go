@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+
+	// Changes made by the rules inside a transaction are rolled back with it, audit entries included
+	failed := errors.New("failed")
+	err := Transaction(db, func(tx *gorm.DB) error {
+		if _, err := CreateTable(tx, NewTable{Number: 1, Seats: 4}); err != nil {
+			return err
+		}
+		if _, err := CreateReservation(tx, NewReservation{Guest: "bob", TableNumber: 1}); err != nil {
+			return err
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+
+	_, err = GetTable(db, 1)
+	assert.Equal(t, apierror.KindNotFound, apierror.KindOf(err))
+	var count int64
+	require.NoError(t, db.Model(&model.AuditEntry{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	require.NoError(t, Transaction(db, func(tx *gorm.DB) error {
+		_, err := CreateTable(tx, NewTable{Number: 1, Seats: 4})
+		return err
+	}))
+	_, err = GetTable(db, 1)
+	require.NoError(t, err)
+}
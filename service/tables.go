@@ -0,0 +1,304 @@
+package service
+
+import (
+	"errors"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/audit"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/tracing"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShrinkMode is what to do when a table is given fewer seats than are reserved on it
+type ShrinkMode string
+
+const (
+	// ShrinkReject refuses the change
+	ShrinkReject ShrinkMode = ""
+	// ShrinkForce makes the change anyway, leaving the table overbooked
+	ShrinkForce ShrinkMode = "force"
+	// ShrinkRebalance moves guests that haven't arrived yet to other tables until everyone left fits
+	ShrinkRebalance ShrinkMode = "rebalance"
+)
+
+// NewTable is a table to create, table numbers must be positive and unique
+type NewTable struct {
+	Number int
+	Seats  int
+}
+
+// TableUpdate holds the changes to make to a table, nil fields are left as they are
+type TableUpdate struct {
+	Number *int
+	Seats  *int
+	Shrink ShrinkMode
+}
+
+// GetTable finds a table by its number
+func GetTable(db *gorm.DB, number int) (model.Table, error) {
+	var table model.Table
+	if err := db.Where("number = ?", number).First(&table).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return table, apierror.NotFound("table %d does not exist", number)
+		}
+		return table, apierror.Internal(err, "failed to get table")
+	}
+	tracing.Table(db.Statement.Context, table.Number)
+	return table, nil
+}
+
+// ListTables gets every table ordered by number, with how many of its seats are reserved, taken and free
+func ListTables(db *gorm.DB) ([]model.TableSummary, error) {
+	summaries := []model.TableSummary{}
+	err := db.Model(&model.Table{}).
+		Select("tables.number, tables.seats, " +
+			"COALESCE(SUM(reservations.accompanying_guests + 1), 0) AS reserved_seats, " +
+			"COALESCE(SUM(CASE WHEN reservations.arrival_time IS NOT NULL THEN reservations.accompanying_guests + 1 ELSE 0 END), 0) AS arrived_seats").
+		Joins("LEFT JOIN reservations ON reservations.table_id = tables.id AND reservations.deleted_at IS NULL").
+		Group("tables.id, tables.number, tables.seats").
+		Order("tables.number").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, apierror.Internal(err, "failed to load tables")
+	}
+	for i := range summaries {
+		summaries[i].FreeSeats = summaries[i].Seats - summaries[i].ReservedSeats
+	}
+	return summaries, nil
+}
+
+// ReservedSeats counts the seats reserved on a table, including every accompanying guest
+func ReservedSeats(db *gorm.DB, table model.Table) (int, error) {
+	var seats int
+	err := db.Model(&model.Reservation{}).
+		Select("COALESCE(SUM(accompanying_guests + 1), 0)").
+		Where("table_id = ?", table.ID).
+		Row().Scan(&seats)
+	if err != nil {
+		return 0, apierror.Internal(err, "failed to count reserved seats")
+	}
+	return seats, nil
+}
+
+// seatsAvailable checks if a table has room for newGuests more people on top of everyone already reserved on it
+func seatsAvailable(db *gorm.DB, table model.Table, newGuests int) (bool, error) {
+	reserved, err := ReservedSeats(db, table)
+	if err != nil {
+		return false, err
+	}
+	return table.Seats-reserved >= newGuests, nil
+}
+
+// lockTable reloads a table inside a transaction, locking its row until the transaction ends. Anything that checks the
+// free seats on a table locks it first, so two requests can't both be given its last seats
+func lockTable(tx *gorm.DB, table model.Table) (model.Table, error) {
+	var locked model.Table
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", table.ID).First(&locked).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return table, apierror.NotFound("table %d does not exist", table.Number)
+		}
+		return table, apierror.Internal(err, "failed to lock table")
+	}
+	return locked, nil
+}
+
+// ValidateTable checks a new table's number and seats, without looking at what is already in the database
+func ValidateTable(in NewTable) error {
+	if in.Number < 1 {
+		return apierror.Validation("table number must be positive")
+	}
+	if in.Seats < 0 {
+		return apierror.Validation("seats can't be negative")
+	}
+	return nil
+}
+
+// CreateTable creates a table
+func CreateTable(db *gorm.DB, in NewTable) (model.Table, error) {
+	tracing.Table(db.Statement.Context, in.Number)
+	table := model.Table{Number: in.Number, Seats: in.Seats}
+	if err := ValidateTable(in); err != nil {
+		return table, err
+	}
+
+	// Check if there is any tables with that number
+	var existing model.Table
+	result := db.Where("number = ?", in.Number).First(&existing)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return table, apierror.Internal(result.Error, "failed to check if table exists")
+	}
+	if result.RowsAffected != 0 {
+		return table, apierror.Conflict("a table exists with that number already")
+	}
+
+	err := Transaction(db, func(tx *gorm.DB) error {
+		if err := tx.Create(&table).Error; err != nil {
+			return apierror.Internal(err, "failed to create table")
+		}
+		dispatch(tx, webhooks.EventTableCreated, table.FormatAsTable())
+		return auditTable(tx, audit.ActionCreate, nil, &table)
+	})
+	return table, err
+}
+
+// UpdateTable changes a table's number and seats. A table can't have fewer seats than are reserved on it unless
+// Shrink is ShrinkForce or ShrinkRebalance, the reservations moved by a rebalance are returned
+func UpdateTable(db *gorm.DB, table model.Table, update TableUpdate) (model.Table, []model.Reservation, error) {
+	var moved []model.Reservation
+	before := table
+
+	if update.Number != nil && *update.Number != table.Number {
+		if *update.Number < 1 {
+			return table, nil, apierror.Validation("table number must be positive")
+		}
+		var existing int64
+		if err := db.Model(&model.Table{}).Where("number = ?", *update.Number).Count(&existing).Error; err != nil {
+			return table, nil, apierror.Internal(err, "failed to check if table exists")
+		}
+		if existing != 0 {
+			return table, nil, apierror.Conflict("a table exists with that number already")
+		}
+		table.Number = *update.Number
+	}
+
+	if update.Seats != nil {
+		if *update.Seats < 0 {
+			return table, nil, apierror.Validation("seats can't be negative")
+		}
+		table.Seats = *update.Seats
+	}
+
+	switch update.Shrink {
+	case ShrinkReject, ShrinkForce, ShrinkRebalance:
+	default:
+		return table, nil, apierror.Validation("unknown shrink option %q, must be force or rebalance", update.Shrink)
+	}
+
+	err := Transaction(db, func(tx *gorm.DB) error {
+		if _, err := lockTable(tx, table); err != nil {
+			return err
+		}
+		reserved, err := ReservedSeats(tx, table)
+		if err != nil {
+			return err
+		}
+
+		if table.Seats < reserved {
+			switch update.Shrink {
+			case ShrinkReject:
+				return apierror.CapacityExceeded("table %d has %d reserved seats, it can't have %d seats without force or rebalance",
+					table.Number, reserved, table.Seats)
+			case ShrinkRebalance:
+				if moved, err = rebalanceTable(tx, table, reserved-table.Seats); err != nil {
+					return err
+				}
+			}
+		}
+
+		values := map[string]interface{}{"number": table.Number, "seats": table.Seats}
+		if err := updateVersioned(tx, &model.Table{}, table.ID, table.Version, values, "table"); err != nil {
+			return err
+		}
+		table.Version++
+		dispatch(tx, webhooks.EventTableUpdated, table.FormatAsTable())
+		return auditTable(tx, audit.ActionUpdate, &before, &table)
+	})
+	if err != nil {
+		return table, nil, err
+	}
+	return table, moved, nil
+}
+
+// rebalanceTable frees up at least excess seats on a table by moving whole parties that haven't arrived yet to the
+// other tables, the most recent reservations are moved first and each goes to the fullest table it fits on
+func rebalanceTable(db *gorm.DB, table model.Table, excess int) ([]model.Reservation, error) {
+	var candidates []model.Reservation
+	err := db.Where("table_id = ? AND arrival_time IS NULL", table.ID).Order("id desc").Find(&candidates).Error
+	if err != nil {
+		return nil, apierror.Internal(err, "failed to load reservations")
+	}
+
+	// The other tables are locked as well, as guests are about to be moved onto them
+	var others []model.Table
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id <> ?", table.ID).Order("number").Find(&others).Error
+	if err != nil {
+		return nil, apierror.Internal(err, "failed to load tables")
+	}
+	free := map[uint]int{}
+	for _, other := range others {
+		reserved, err := ReservedSeats(db, other)
+		if err != nil {
+			return nil, err
+		}
+		free[other.ID] = other.Seats - reserved
+	}
+
+	var moved []model.Reservation
+	for _, reservation := range candidates {
+		if excess <= 0 {
+			break
+		}
+		party := reservation.AccompanyingGuests + 1
+
+		best := -1
+		for i, other := range others {
+			if free[other.ID] >= party && (best == -1 || free[other.ID] < free[others[best].ID]) {
+				best = i
+			}
+		}
+		if best == -1 {
+			continue
+		}
+
+		destination := others[best]
+		values := map[string]interface{}{"table_id": destination.ID}
+		if err := updateVersioned(db, &model.Reservation{}, reservation.ID, reservation.Version, values, "reservation"); err != nil {
+			return nil, err
+		}
+		before := reservation
+		before.Table = table
+		reservation.Version++
+		reservation.Table = destination
+		if err := auditReservation(db, audit.ActionMove, &before, &reservation); err != nil {
+			return nil, err
+		}
+		dispatch(db, webhooks.EventReservationUpdated, reservation.FormatAsReservation())
+		free[destination.ID] -= party
+		excess -= party
+		moved = append(moved, reservation)
+	}
+
+	if excess > 0 {
+		return nil, apierror.CapacityExceeded("there aren't enough free seats on other tables to move %d more guests from table %d",
+			excess, table.Number)
+	}
+	return moved, nil
+}
+
+// DeleteTable soft deletes a table, tables with reservations can't be deleted
+func DeleteTable(db *gorm.DB, table model.Table) error {
+	return Transaction(db, func(tx *gorm.DB) error {
+		// Lock the table first so a reservation can't be made on it while it is being deleted
+		if _, err := lockTable(tx, table); err != nil {
+			return err
+		}
+
+		// Check if there is any reservations and stop table deletion
+		var reservation model.Reservation
+		result := tx.Where("table_id = ?", table.ID).First(&reservation)
+		if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return apierror.Internal(result.Error, "failed to query reservations")
+		}
+		if reservation.ID != 0 { // Same as a nil check
+			return apierror.Conflict("cannot delete a table with a reservation")
+		}
+
+		if err := deleteVersioned(tx, &model.Table{}, table.ID, table.Version, "table"); err != nil {
+			return err
+		}
+		dispatch(tx, webhooks.EventTableDeleted, table.FormatAsTable())
+		return auditTable(tx, audit.ActionDelete, &table, nil)
+	})
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/ctompkinson/guest-list/apierror"
+	"github.com/ctompkinson/guest-list/database/databasetest"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/ctompkinson/guest-list/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCreateTable(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	db.Create(&model.Table{Number: 1, Seats: 4})
+
+	cases := []struct {
+		name         string
+		input        NewTable
+		expectedKind apierror.Kind
+	}{
+		{"valid", NewTable{Number: 2, Seats: 8}, ""},
+		{"noSeats", NewTable{Number: 3, Seats: 0}, ""},
+		{"duplicate", NewTable{Number: 1, Seats: 8}, apierror.KindConflict},
+		{"zeroNumber", NewTable{Number: 0, Seats: 8}, apierror.KindValidation},
+		{"negativeSeats", NewTable{Number: 4, Seats: -1}, apierror.KindValidation},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			table, err := CreateTable(db, c.input)
+			if c.expectedKind != "" {
+				require.Error(t, err)
+				assert.Equal(t, c.expectedKind, apierror.KindOf(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.NotZero(t, table.ID)
+
+			found, err := GetTable(db, c.input.Number)
+			require.NoError(t, err)
+			assert.Equal(t, c.input.Seats, found.Seats)
+		})
+	}
+}
+
+func TestUpdateTable(t *testing.T) {
+	t.Parallel()
+	three, one, two := 3, 1, 2
+
+	cases := []struct {
+		name          string
+		update        TableUpdate
+		expectedKind  apierror.Kind
+		expectedSeats map[int]int
+		expectedMoved []string
+	}{
+		{"grow", TableUpdate{Seats: &three}, "", map[int]int{1: 3, 2: 2}, nil},
+		{"renumber", TableUpdate{Number: &three}, "", map[int]int{2: 2, 3: 5}, nil},
+		{"renumberTaken", TableUpdate{Number: &two}, apierror.KindConflict, map[int]int{1: 5, 2: 2}, nil},
+		{"shrinkBelowReserved", TableUpdate{Seats: &one}, apierror.KindCapacityExceeded, map[int]int{1: 5, 2: 2}, nil},
+		{"force", TableUpdate{Seats: &one, Shrink: ShrinkForce}, "", map[int]int{1: 1, 2: 2}, nil},
+		{"rebalance", TableUpdate{Seats: &two, Shrink: ShrinkRebalance}, "", map[int]int{1: 2, 2: 2}, []string{"alice"}},
+		{"rebalanceNoRoom", TableUpdate{Seats: &one, Shrink: ShrinkRebalance}, apierror.KindCapacityExceeded, map[int]int{1: 5, 2: 2}, nil},
+		{"unknownShrink", TableUpdate{Seats: &one, Shrink: "squeeze"}, apierror.KindValidation, map[int]int{1: 5, 2: 2}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			db := databasetest.New(t)
+			table := model.Table{Number: 1, Seats: 5}
+			db.Create(&table)
+			db.Create(&model.Table{Number: 2, Seats: 2})
+			db.Create(&model.Reservation{Guest: "bob", AccompanyingGuests: 1, Table: table})
+			db.Create(&model.Reservation{Guest: "alice", Table: table})
+
+			updated, moved, err := UpdateTable(db, table, c.update)
+			if c.expectedKind != "" {
+				require.Error(t, err)
+				assert.Equal(t, c.expectedKind, apierror.KindOf(err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, table.Version+1, updated.Version)
+			}
+
+			var names []string
+			for _, r := range moved {
+				names = append(names, r.Guest)
+			}
+			assert.Equal(t, c.expectedMoved, names)
+
+			summaries, err := ListTables(db)
+			require.NoError(t, err)
+			seats := map[int]int{}
+			for _, s := range summaries {
+				seats[s.Number] = s.Seats
+			}
+			assert.Equal(t, c.expectedSeats, seats)
+		})
+	}
+}
+
+// Not parallel, the test receiver listens on localhost so private webhook targets are allowed while it runs
+func TestUpdateTable_RebalanceWebhook(t *testing.T) {
+	webhooks.AllowPrivate = true
+	defer func() { webhooks.AllowPrivate = false }()
+
+	var mu sync.Mutex
+	var events []webhooks.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var e webhooks.Event
+		if json.Unmarshal(body, &e) == nil {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}
+	}))
+	defer receiver.Close()
+
+	db := databasetest.New(t)
+	table := model.Table{Number: 1, Seats: 5}
+	db.Create(&table)
+	db.Create(&model.Table{Number: 2, Seats: 2})
+	db.Create(&model.Reservation{Guest: "bob", AccompanyingGuests: 1, Table: table})
+	db.Create(&model.Reservation{Guest: "alice", Table: table})
+	db.Create(&model.WebhookSubscription{URL: receiver.URL, Secret: "foo", Events: webhooks.EventReservationUpdated})
+
+	two := 2
+	_, _, err := UpdateTable(db, table, TableUpdate{Seats: &two, Shrink: ShrinkRebalance})
+	require.NoError(t, err)
+	webhooks.Wait()
+
+	require.Len(t, events, 1)
+	assert.Equal(t, webhooks.EventReservationUpdated, events[0].Type)
+	data := events[0].Data.(map[string]interface{})
+	assert.Equal(t, "alice", data["name"])
+	assert.Equal(t, float64(2), data["table"])
+}
+
+func TestDeleteTable(t *testing.T) {
+	t.Parallel()
+	db := databasetest.New(t)
+	empty := model.Table{Number: 1, Seats: 4}
+	db.Create(&empty)
+	booked := model.Table{Number: 2, Seats: 4}
+	db.Create(&booked)
+	db.Create(&model.Reservation{Guest: "bob", Table: booked})
+
+	require.NoError(t, DeleteTable(db, empty))
+	_, err := GetTable(db, 1)
+	assert.Equal(t, apierror.KindNotFound, apierror.KindOf(err))
+
+	err = DeleteTable(db, booked)
+	assert.Equal(t, apierror.KindConflict, apierror.KindOf(err))
+
+	// A stale copy of the table can't be deleted
+	_, _, err = UpdateTable(db, booked, TableUpdate{})
+	require.NoError(t, err)
+	db.Where("table_id = ?", booked.ID).Delete(&model.Reservation{})
+	err = DeleteTable(db, booked)
+	assert.Equal(t, apierror.KindPreconditionFailed, apierror.KindOf(err))
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/ctompkinson/guest-list/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"os"
+)
+
+// ServiceName is what traces are reported as, unless OTEL_SERVICE_NAME is set
+const ServiceName = "guest-list"
+
+// The attributes added to request spans for the request ID and the table and reservation the request is working on
+const (
+	RequestID     = attribute.Key("guestlist.request.id")
+	GuestName     = attribute.Key("guestlist.guest.name")
+	TableNumber   = attribute.Key("guestlist.table.number")
+	ReservationID = attribute.Key("guestlist.reservation.id")
+)
+
+// Tracer starts every span the server makes, it does nothing until Configure sets up an exporter
+var Tracer = otel.Tracer("github.com/ctompkinson/guest-list")
+
+// Configure sets up tracing with an exporter, which is none, stdout or otlp. The otlp exporter sends to a collector
+// over HTTP and is configured with the standard OTEL_EXPORTER_OTLP_* variables, sampling with OTEL_TRACES_SAMPLER. The
+// returned function flushes any spans that haven't been sent yet
+func Configure(ctx context.Context, exporter string, stdout io.Writer) (func(context.Context) error, error) {
+	var exp sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(stdout))
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, must be none, stdout or otlp", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// ConfigureFromEnv configures tracing from GUESTLIST_TRACING_EXPORTER
+func ConfigureFromEnv(ctx context.Context) (func(context.Context) error, error) {
+	return Configure(ctx, os.Getenv("GUESTLIST_TRACING_EXPORTER"), os.Stdout)
+}
+
+// Reservation adds a reservation's guest, table and ID to the span in the context. The reservation's table must be
+// loaded
+func Reservation(ctx context.Context, r model.Reservation) {
+	if ctx == nil {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(
+		GuestName.String(r.Guest),
+		TableNumber.Int(r.Table.Number),
+		ReservationID.Int64(int64(r.ID)),
+	)
+}
+
+// Guest adds a guest's name to the span in the context, for requests about a guest whose reservation hasn't been
+// loaded
+func Guest(ctx context.Context, name string) {
+	if ctx == nil {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(GuestName.String(name))
+}
+
+// Table adds a table's number to the span in the context
+func Table(ctx context.Context, number int) {
+	if ctx == nil {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(TableNumber.Int(number))
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	_, err := Configure(context.Background(), "zipkin", nil)
+	assert.EqualError(t, err, `unknown tracing exporter "zipkin", must be none, stdout or otlp`)
+
+	shutdown, err := Configure(context.Background(), "none", nil)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	var buf bytes.Buffer
+	shutdown, err = Configure(context.Background(), "stdout", &buf)
+	require.NoError(t, err)
+	_, span := Tracer.Start(context.Background(), "check in")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, buf.String(), `"Name":"check in"`)
+	assert.Contains(t, buf.String(), `"Value":"guest-list"`)
+}
+
+func TestAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	ctx, span := tracer.Start(context.Background(), "request")
+	Guest(ctx, "bob")
+	Reservation(ctx, model.Reservation{Model: gorm.Model{ID: 7}, Guest: "bob", Table: model.Table{Number: 3}})
+	span.End()
+
+	// Nothing to annotate outside of a request
+	Guest(nil, "bob")
+	Table(context.Background(), 3)
+
+	require.Len(t, recorder.Ended(), 1)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		GuestName.String("bob"),
+		TableNumber.Int(3),
+		ReservationID.Int64(7),
+	}, recorder.Ended()[0].Attributes())
+}
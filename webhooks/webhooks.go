@@ -0,0 +1,288 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ctompkinson/guest-list/logging"
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	EventReservationCreated  = "reservation.created"
+	EventReservationUpdated  = "reservation.updated"
+	EventReservationDeleted  = "reservation.deleted"
+	EventReservationRestored = "reservation.restored"
+	EventGuestArrived        = "guest.arrived"
+	EventTableCreated        = "table.created"
+	EventTableUpdated        = "table.updated"
+	EventTableDeleted        = "table.deleted"
+	EventTableRestored       = "table.restored"
+
+	SignatureHeader = "X-Guestlist-Signature"
+	TimestampHeader = "X-Guestlist-Timestamp"
+	EventHeader     = "X-Guestlist-Event"
+	DeliveryHeader  = "X-Guestlist-Delivery"
+)
+
+var (
+	// Events lists every event type a subscription can filter on
+	Events = []string{
+		EventReservationCreated,
+		EventReservationUpdated,
+		EventReservationDeleted,
+		EventReservationRestored,
+		EventGuestArrived,
+		EventTableCreated,
+		EventTableUpdated,
+		EventTableDeleted,
+		EventTableRestored,
+	}
+
+	// MaxAttempts is how many times a delivery is tried before giving up
+	MaxAttempts = 5
+	// InitialBackoff is how long to wait after the first failed attempt, it doubles after every failure
+	InitialBackoff = 2 * time.Second
+	// AllowPrivate lets subscriptions use loopback, private and link-local addresses, for local development. Otherwise
+	// they are refused so a webhook can't be used to reach internal services or a cloud metadata endpoint
+	AllowPrivate = false
+
+	// ErrInvalidURL is returned for subscription URLs that aren't absolute http or https URLs
+	ErrInvalidURL = errors.New("url must be an absolute http or https url")
+	// ErrPrivateTarget is returned for subscription URLs that point at a loopback, private or link-local address
+	ErrPrivateTarget = errors.New("url must not point at a loopback, private or link-local address")
+
+	client = &http.Client{
+		Timeout: 10 * time.Second,
+		// Every connection is checked once the host is resolved, which also covers redirects and hosts that resolved
+		// to a public address when the subscription was made. Proxies aren't used as they would hide the real target
+		Transport: &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: checkDial}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	pending sync.WaitGroup
+)
+
+// Event is the body that is posted to every subscriber
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// IsEvent checks if the given string is a known event type
+func IsEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL checks a subscription URL is an absolute http or https URL that doesn't point at a loopback, private or
+// link-local address. Hosts that can't be resolved yet are accepted as every delivery is checked again when it connects
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if AllowPrivate {
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateTarget
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ips, _ = net.LookupIP(host)
+	}
+	for _, ip := range ips {
+		if isPrivate(ip) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+// isPrivate reports whether an address is this host, a private network or link-local, which includes the
+// 169.254.169.254 metadata endpoint of most cloud providers
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast()
+}
+
+// checkDial refuses connections to private addresses, it is run with the resolved address just before connecting
+func checkDial(network, address string, _ syscall.RawConn) error {
+	if AllowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isPrivate(ip) {
+		return ErrPrivateTarget
+	}
+	return nil
+}
+
+// NewSecret generates a random secret used to sign payloads
+func NewSecret() (string, error) {
+	return randomHex(32)
+}
+
+// Sign creates the signature sent with every delivery, it is a hex encoded HMAC-SHA256 of the timestamp and payload
+// joined by a full stop, receivers should recompute it with their secret and compare
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait before the next attempt given how many attempts have been made
+func Backoff(attempt int) time.Duration {
+	return InitialBackoff * time.Duration(1<<uint(attempt-1))
+}
+
+// Dispatch sends an event to every subscription in db interested in it, deliveries happen in the background so the
+// caller is never slowed down by a subscriber. Deliveries and their retries only live in this process, any still
+// pending when it stops are lost, so delivery is at most once
+func Dispatch(db *gorm.DB, eventType string, data interface{}) {
+	if db == nil {
+		return
+	}
+	// Deliveries outlive the request, so they are recorded without its context
+	db = db.WithContext(context.Background())
+
+	var subscriptions []model.WebhookSubscription
+	if err := db.Find(&subscriptions).Error; err != nil {
+		logging.Log.WithError(err).Error("failed to load webhook subscriptions")
+		return
+	}
+
+	for _, s := range subscriptions {
+		if !s.Wants(eventType) {
+			continue
+		}
+
+		id, err := randomHex(16)
+		if err != nil {
+			logging.Log.WithError(err).Error("failed to generate webhook delivery id")
+			return
+		}
+		payload, err := json.Marshal(Event{
+			ID:         id,
+			Type:       eventType,
+			OccurredAt: time.Now().UTC(),
+			Data:       data,
+		})
+		if err != nil {
+			logging.Log.WithError(err).WithField("event", eventType).Error("failed to marshal webhook payload")
+			return
+		}
+
+		pending.Add(1)
+		go func(s model.WebhookSubscription) {
+			defer pending.Done()
+			deliver(db, s, id, eventType, payload)
+		}(s)
+	}
+}
+
+// Wait blocks until all in flight deliveries, including their retries, have finished
+func Wait() {
+	pending.Wait()
+}
+
+// deliver posts the payload to a subscriber, retrying with exponential backoff and recording every attempt
+func deliver(db *gorm.DB, s model.WebhookSubscription, id, eventType string, payload []byte) {
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		status, err := post(s, id, eventType, payload)
+
+		record := model.WebhookDelivery{
+			WebhookSubscriptionID: s.ID,
+			DeliveryID:            id,
+			Event:                 eventType,
+			Payload:               string(payload),
+			Attempt:               attempt,
+			StatusCode:            status,
+			Succeeded:             err == nil,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		if dbErr := db.Create(&record).Error; dbErr != nil {
+			logging.Log.WithError(dbErr).WithField("delivery_id", id).Error("failed to record webhook delivery")
+		}
+
+		if err == nil {
+			return
+		}
+		logging.Log.WithError(err).WithFields(logrus.Fields{
+			"delivery_id": id,
+			"event":       eventType,
+			"url":         s.URL,
+			"attempt":     attempt,
+		}).Warn("webhook delivery failed")
+		if attempt < MaxAttempts {
+			time.Sleep(Backoff(attempt))
+		}
+	}
+}
+
+// post makes a single signed delivery attempt
+func post(s model.WebhookSubscription, id, eventType string, payload []byte) (int, error) {
+	req, err := http.NewRequest("POST", s.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(DeliveryHeader, id)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(s.Secret, timestamp, payload))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("subscriber responded with %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"github.com/ctompkinson/guest-list/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"type":"guest.arrived"}`)
+
+	sig := Sign("secret", 1600000000, payload)
+	assert.Equal(t, sig, Sign("secret", 1600000000, payload))
+	assert.Contains(t, sig, "sha256=")
+	assert.NotEqual(t, sig, Sign("other", 1600000000, payload))
+	assert.NotEqual(t, sig, Sign("secret", 1600000001, payload))
+}
+
+func TestBackoff(t *testing.T) {
+	InitialBackoff = time.Second
+
+	assert.Equal(t, time.Second, Backoff(1))
+	assert.Equal(t, 2*time.Second, Backoff(2))
+	assert.Equal(t, 8*time.Second, Backoff(4))
+}
+
+func TestIsEvent(t *testing.T) {
+	assert.True(t, IsEvent(EventGuestArrived))
+	assert.False(t, IsEvent("guest.left"))
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://203.0.113.10/hook"))
+	assert.ErrorIs(t, ValidateURL("ftp://203.0.113.10/hook"), ErrInvalidURL)
+	assert.ErrorIs(t, ValidateURL("/hook"), ErrInvalidURL)
+
+	for _, u := range []string{
+		"http://localhost/hook",
+		"http://127.0.0.1:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fd00:ec2::254]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		assert.ErrorIs(t, ValidateURL(u), ErrPrivateTarget, u)
+	}
+}
+
+func TestDeliveryToPrivateAddress(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	// The check is made when connecting, so a subscription that later resolves to a private address is still refused
+	_, err := post(model.WebhookSubscription{URL: receiver.URL, Secret: "foo"}, "1", EventGuestArrived, []byte("{}"))
+	assert.ErrorIs(t, err, ErrPrivateTarget)
+	assert.False(t, called)
+}